@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"01-zap/rotate"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testKey = []byte("test-key")
+
+func testOptions(dir string) Options {
+	return Options{
+		Rotate: rotate.Options{
+			Pattern:  filepath.Join(dir, "audit.log.%Y%m%d"),
+			LinkName: filepath.Join(dir, "audit.log"),
+			MaxSize:  1000, // 每个文件几条记录，测试跨文件的哈希链
+			Compress: true,
+		},
+		Key:             testKey,
+		CheckpointEvery: 3,
+	}
+}
+
+// writeEvents 写入n条用户操作
+func writeEvents(t *testing.T, opts Options, from, n int) {
+	t.Helper()
+	w, err := Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := from; i < from+n; i++ {
+		err := w.Log(Event{
+			Actor:    "admin",
+			Action:   "user.update",
+			Resource: fmt.Sprintf("user:%d", i),
+			Data:     map[string]any{"username": fmt.Sprintf("user%d", i), "age": 20 + i, "note": "<b>&</b>"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func auditFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := rotate.Files(filepath.Join(dir, "audit.log.%Y%m%d"), filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestChainAcrossSegmentsAndRestarts(t *testing.T) {
+	dir := t.TempDir()
+	opts := testOptions(dir)
+	writeEvents(t, opts, 1, 7)
+	writeEvents(t, opts, 8, 5)
+
+	files := auditFiles(t, dir)
+	if len(files) < 3 {
+		t.Fatalf("expected several rotated segments, got %v", files)
+	}
+
+	res, err := Verify(files, VerifyOptions{Key: testKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一次7条事件，每3条一个检查点，Close时为第7条补一个；第二次5条事件，Close时为最后2条补一个
+	if res.Records != 17 || res.Checkpoints != 5 || res.SignedSeq != res.LastSeq {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+// tamper 修改包含substr的那一行
+func tamper(t *testing.T, dir, substr string, fn func(line string) string) {
+	t.Helper()
+	for _, path := range auditFiles(t, dir) {
+		if strings.HasSuffix(path, ".gz") {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(string(data), "\n")
+		for i, line := range lines {
+			if strings.Contains(line, substr) {
+				lines[i] = fn(line)
+				if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+		}
+	}
+	t.Fatalf("no uncompressed line contains %q", substr)
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(line string) string
+		reason string
+	}{
+		{"modified", func(line string) string { return strings.Replace(line, `"age":31`, `"age":18`, 1) }, "hash mismatch"},
+		// user:11 是第14条记录，删除后在下一条记录处发现
+		{"deleted", func(string) string { return "" }, "records 14-14 are missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := testOptions(dir)
+			opts.Rotate.Compress = false
+			writeEvents(t, opts, 1, 12)
+
+			tamper(t, dir, `"user:11"`, tt.modify)
+			_, err := Verify(auditFiles(t, dir), VerifyOptions{Key: testKey})
+
+			var p *Problem
+			if !errors.As(err, &p) {
+				t.Fatalf("expected *Problem, got %v", err)
+			}
+			if !strings.Contains(p.Reason, tt.reason) {
+				t.Errorf("got %v, want %s", p, tt.reason)
+			}
+		})
+	}
+}
+
+func TestVerifyDetectsRewrittenChain(t *testing.T) {
+	dir := t.TempDir()
+	opts := testOptions(dir)
+	writeEvents(t, opts, 1, 4)
+
+	// 使用其它密钥写入的检查点无法通过校验
+	_, err := Verify(auditFiles(t, dir), VerifyOptions{Key: []byte("other-key")})
+	var p *Problem
+	if !errors.As(err, &p) || !strings.Contains(p.Reason, "invalid checkpoint signature") || p.Seq != 4 {
+		t.Fatalf("got %v, want invalid signature at seq 4", err)
+	}
+}
+
+func TestOpenRejectsCorruptTail(t *testing.T) {
+	dir := t.TempDir()
+	opts := testOptions(dir)
+	opts.Rotate.MaxSize = 0
+	writeEvents(t, opts, 1, 1)
+
+	f, err := os.OpenFile(filepath.Join(dir, "audit.log"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":3,"ty`)
+	f.Close()
+
+	if _, err := Open(opts); err == nil {
+		t.Fatal("expected error for corrupt last record")
+	}
+}
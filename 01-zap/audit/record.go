@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// 记录类型
+const (
+	TypeEvent      = "event"      // 审计事件
+	TypeCheckpoint = "checkpoint" // 检查点，hmac为对hash的签名
+)
+
+// Record 审计文件中的一行
+// hash 是去掉hash和hmac之后规范化JSON的SHA-256，prev 是上一条记录的hash，第一条记录为空
+type Record struct {
+	Seq      uint64         `json:"seq"`
+	Time     string         `json:"time"` // UTC，RFC3339Nano
+	Type     string         `json:"type"`
+	Actor    string         `json:"actor,omitempty"`
+	Action   string         `json:"action,omitempty"`
+	Resource string         `json:"resource,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+	Prev     string         `json:"prev"`
+	Hash     string         `json:"hash,omitempty"`
+	HMAC     string         `json:"hmac,omitempty"`
+}
+
+// canonical 规范化JSON：对象的key按字典序排列，数字保持原样，不转义HTML字符，去掉hash和hmac
+// 写入和校验都先解析为map再编码，保证两边得到相同的字节
+func canonical(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	delete(m, "hash")
+	delete(m, "hmac")
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(m); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// hashOf 计算一行记录的hash
+func hashOf(raw []byte) (string, error) {
+	c, err := canonical(raw)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(c)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sign 检查点的签名
+func sign(key []byte, hash string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// seal 计算hash和检查点签名，返回写入文件的一行
+func seal(r *Record, key []byte) ([]byte, error) {
+	r.Hash, r.HMAC = "", ""
+	raw, err := marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Hash, err = hashOf(raw); err != nil {
+		return nil, err
+	}
+	if r.Type == TypeCheckpoint && len(key) > 0 {
+		r.HMAC = sign(key, r.Hash)
+	}
+	line, err := marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+func marshal(r *Record) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(r); err != nil {
+		return nil, fmt.Errorf("audit: encode record %d: %w", r.Seq, err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// VerifyOptions 校验配置
+type VerifyOptions struct {
+	Key     []byte // 检查点的HMAC密钥，为空时不校验签名
+	Partial bool   // 允许从中间开始，如最早的文件已经归档，此时第一条记录的prev不做校验
+}
+
+// Result 校验结果
+type Result struct {
+	Files       int
+	Records     uint64 // 记录总数，包括检查点
+	Checkpoints int
+	LastSeq     uint64
+	SignedSeq   uint64 // 最后一个签名有效的检查点，之后的记录没有被签名保护
+}
+
+// Problem 第一条校验失败的记录
+type Problem struct {
+	File   string
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+func (p *Problem) Error() string {
+	if p.Seq == 0 {
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Reason)
+	}
+	return fmt.Sprintf("%s:%d: seq %d: %s", p.File, p.Line, p.Seq, p.Reason)
+}
+
+// segment 一个文件及其第一条记录的序号
+type segment struct {
+	path  string
+	first uint64
+}
+
+// Verify 按记录序号排列文件后依次校验哈希链和检查点签名，返回第一处问题（*Problem）
+// 文件顺序由内容决定，不依赖文件名和修改时间
+func Verify(files []string, opts VerifyOptions) (*Result, error) {
+	segments, err := sortSegments(files)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Files: len(segments)}
+	var prev string
+	started := false
+
+	for _, seg := range segments {
+		err := readLines(seg.path, func(n int, line []byte) error {
+			fail := func(seq uint64, format string, args ...any) error {
+				return &Problem{File: seg.path, Line: n, Seq: seq, Reason: fmt.Sprintf(format, args...)}
+			}
+
+			var r Record
+			if err := json.Unmarshal(line, &r); err != nil {
+				return fail(0, "invalid record: %v", err)
+			}
+			hash, err := hashOf(line)
+			if err != nil {
+				return fail(r.Seq, "invalid record: %v", err)
+			}
+			if r.Hash != hash {
+				return fail(r.Seq, "hash mismatch, record was modified")
+			}
+
+			switch {
+			case !started && opts.Partial:
+			case !started && r.Seq != 1:
+				return fail(r.Seq, "records 1-%d are missing", r.Seq-1)
+			case !started && r.Prev != "":
+				return fail(r.Seq, "first record has prev hash, earlier records are missing")
+			case started && r.Seq > res.LastSeq+1:
+				return fail(r.Seq, "records %d-%d are missing", res.LastSeq+1, r.Seq-1)
+			case started && r.Seq <= res.LastSeq:
+				return fail(r.Seq, "duplicate or reordered record, expected seq %d", res.LastSeq+1)
+			case started && r.Prev != prev:
+				return fail(r.Seq, "prev hash does not match record %d, it was modified or replaced", res.LastSeq)
+			}
+
+			if r.Type == TypeCheckpoint {
+				res.Checkpoints++
+				if len(opts.Key) > 0 {
+					if r.HMAC == "" {
+						return fail(r.Seq, "checkpoint is not signed")
+					}
+					if !hmac.Equal([]byte(r.HMAC), []byte(sign(opts.Key, r.Hash))) {
+						return fail(r.Seq, "invalid checkpoint signature, records since seq %d may have been rewritten", res.SignedSeq+1)
+					}
+					res.SignedSeq = r.Seq
+				}
+			}
+
+			started = true
+			prev = r.Hash
+			res.LastSeq = r.Seq
+			res.Records++
+			return nil
+		})
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// sortSegments 读取每个文件的第一条记录，按序号排序，跳过空文件
+func sortSegments(files []string) ([]segment, error) {
+	errStop := errors.New("stop")
+	var segments []segment
+	for _, path := range files {
+		seg := segment{path: path}
+		found := false
+		err := readLines(path, func(n int, line []byte) error {
+			var r Record
+			if err := json.Unmarshal(line, &r); err != nil {
+				return &Problem{File: path, Line: n, Reason: fmt.Sprintf("invalid record: %v", err)}
+			}
+			seg.first, found = r.Seq, true
+			return errStop
+		})
+		if err != nil && !errors.Is(err, errStop) {
+			return nil, err
+		}
+		if found {
+			segments = append(segments, seg)
+		}
+	}
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].first < segments[j].first
+	})
+	return segments, nil
+}
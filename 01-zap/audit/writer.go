@@ -0,0 +1,270 @@
+// Package audit 防篡改的审计日志，与应用日志分开保存
+//
+// 每条记录通过SHA-256与上一条记录组成哈希链，定期写入带HMAC签名的检查点，
+// 文件由rotate包轮转，重启和轮转后从最后一条记录继续，哈希链跨越所有文件。
+// Verify（或 cmd/audit verify）可以找出第一条被修改、删除或者顺序被调整的记录。
+package audit
+
+import (
+	"01-zap/rotate"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options 审计日志配置
+type Options struct {
+	Rotate             rotate.Options // 文件轮转，Pattern必填；不要设置MaxAge和MaxCount，删除旧文件会导致校验失败
+	Key                []byte         // 检查点的HMAC密钥，为空时检查点不签名，只能校验哈希链
+	CheckpointEvery    int            // 每多少条记录写一个检查点，默认 1000
+	CheckpointInterval time.Duration  // 有新记录时最多间隔多久写一个检查点，默认 1m
+}
+
+const (
+	defaultCheckpointEvery    = 1000
+	defaultCheckpointInterval = time.Minute
+)
+
+// Event 一次需要审计的操作
+type Event struct {
+	Actor    string         // 操作者，如用户名
+	Action   string         // 操作，如 user.create
+	Resource string         // 操作对象，如 user:3
+	Data     map[string]any // 其它信息，不要包含密码等敏感字段
+}
+
+// ErrClosed Close之后继续写入
+var ErrClosed = errors.New("audit: writer is closed")
+
+// Writer 追加审计记录，可以被多个goroutine同时使用
+type Writer struct {
+	opts  Options
+	clock func() time.Time
+
+	mu      sync.Mutex
+	w       *rotate.Writer
+	seq     uint64
+	prev    string
+	pending int // 上一个检查点之后的记录数
+	closed  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open 打开审计日志，从已有文件的最后一条记录继续哈希链
+func Open(opts Options) (*Writer, error) {
+	if opts.CheckpointEvery <= 0 {
+		opts.CheckpointEvery = defaultCheckpointEvery
+	}
+	if opts.CheckpointInterval <= 0 {
+		opts.CheckpointInterval = defaultCheckpointInterval
+	}
+	clock := opts.Rotate.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	last, err := lastRecord(opts.Rotate.Pattern, opts.Rotate.LinkName)
+	if err != nil {
+		return nil, err
+	}
+	w, err := rotate.New(opts.Rotate)
+	if err != nil {
+		return nil, err
+	}
+
+	aw := &Writer{
+		opts:  opts,
+		clock: clock,
+		w:     w,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if last != nil {
+		aw.seq, aw.prev = last.Seq, last.Hash
+		// 上次没有正常关闭时，最后一个检查点之后的记录还没有签名
+		if last.Type != TypeCheckpoint {
+			aw.pending = 1
+		}
+	}
+	go aw.run()
+	return aw, nil
+}
+
+// Log 写入一条审计事件
+func (w *Writer) Log(e Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+	if err := w.append(Record{
+		Type:     TypeEvent,
+		Actor:    e.Actor,
+		Action:   e.Action,
+		Resource: e.Resource,
+		Data:     e.Data,
+	}); err != nil {
+		return err
+	}
+	w.pending++
+	if w.pending >= w.opts.CheckpointEvery {
+		return w.checkpoint()
+	}
+	return nil
+}
+
+// Checkpoint 立即写入检查点，并把文件刷到磁盘
+func (w *Writer) Checkpoint() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+	return w.checkpoint()
+}
+
+// Close 有未签名的记录时写入最后一个检查点，然后关闭文件
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.pending > 0 {
+		err = w.checkpoint()
+	}
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+	return errors.Join(err, w.w.Close())
+}
+
+// append 补全序号、时间和哈希后写入，写入失败时不推进哈希链，调用方持有mu
+func (w *Writer) append(r Record) error {
+	r.Seq = w.seq + 1
+	r.Time = w.clock().UTC().Format(time.RFC3339Nano)
+	r.Prev = w.prev
+
+	line, err := seal(&r, w.opts.Key)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(line); err != nil {
+		return fmt.Errorf("audit: write record %d: %w", r.Seq, err)
+	}
+	w.seq, w.prev = r.Seq, r.Hash
+	return nil
+}
+
+// checkpoint 写入检查点，调用方持有mu
+func (w *Writer) checkpoint() error {
+	if err := w.append(Record{
+		Type: TypeCheckpoint,
+		Data: map[string]any{"records": w.pending},
+	}); err != nil {
+		return err
+	}
+	w.pending = 0
+	return w.w.Sync()
+}
+
+// run 定期为新记录写入检查点
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.CheckpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			if !w.closed && w.pending > 0 {
+				// 失败时下一次再试，Log也会返回写入错误
+				_ = w.checkpoint()
+			}
+			w.mu.Unlock()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// lastRecord 按写入顺序从最新的文件开始找最后一条记录，没有记录时返回nil
+func lastRecord(pattern, linkName string) (*Record, error) {
+	files, err := rotate.Files(pattern, linkName)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		var last []byte
+		if err := readLines(files[i], func(_ int, line []byte) error {
+			last = append(last[:0], line...)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if len(last) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(last, &r); err != nil || r.Hash == "" {
+			return nil, fmt.Errorf("audit: last record in %s is corrupt, run verify before writing", files[i])
+		}
+		return &r, nil
+	}
+	return nil, nil
+}
+
+const maxLineSize = 1024 * 1024
+
+// readLines 逐行读取文件，.gz文件先解压，跳过空行
+func readLines(path string, fn func(n int, line []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxLineSize)
+	n := 0
+	for sc.Scan() {
+		n++
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(n, line); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
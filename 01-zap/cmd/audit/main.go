@@ -0,0 +1,102 @@
+// audit 审计日志工具
+//
+//	AUDIT_HMAC_KEY=xxx audit verify logs/audit/audit.log.*
+//	audit verify -partial logs/audit/audit.log.202501*
+//
+// 文件按第一条记录的序号排序，可以包含轮转后的gzip压缩文件，发现问题时输出第一条出错的记录并以状态码1退出
+package main
+
+import (
+	"01-zap/audit"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "verify" {
+		fmt.Fprintln(os.Stderr, "用法: audit verify [选项] 文件...")
+		os.Exit(2)
+	}
+	if err := verify(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "audit:", err)
+		os.Exit(1)
+	}
+}
+
+func verify(args []string) error {
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: audit verify [选项] 文件...")
+		fs.PrintDefaults()
+	}
+	var (
+		keyEnv  = fs.String("key-env", "AUDIT_HMAC_KEY", "保存HMAC密钥的环境变量，为空时不校验检查点签名")
+		partial = fs.Bool("partial", false, "允许从中间开始校验，如最早的文件已经归档")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files, err := expandFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return errors.New("no files")
+	}
+
+	var key []byte
+	if *keyEnv != "" {
+		key = []byte(os.Getenv(*keyEnv))
+	}
+	res, err := audit.Verify(files, audit.VerifyOptions{Key: key, Partial: *partial})
+	if err != nil {
+		var p *audit.Problem
+		if errors.As(err, &p) {
+			fmt.Printf("FAIL %s\n", p)
+			os.Exit(1)
+		}
+		return err
+	}
+
+	fmt.Printf("OK %d records in %d files, last seq %d, %d checkpoints\n", res.Records, res.Files, res.LastSeq, res.Checkpoints)
+	switch {
+	case len(key) == 0 && *keyEnv != "":
+		fmt.Printf("WARN checkpoint signatures were not verified, %s is not set\n", *keyEnv)
+	case len(key) == 0:
+		fmt.Println("WARN checkpoint signatures were not verified")
+	case res.SignedSeq < res.LastSeq:
+		fmt.Printf("WARN records %d-%d are not covered by a signed checkpoint\n", res.SignedSeq+1, res.LastSeq)
+	}
+	return nil
+}
+
+// expandFiles 展开通配符，去掉指向同一文件的路径（如轮转的软链）
+func expandFiles(args []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no such file: %s", arg)
+		}
+		for _, path := range matches {
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				return nil, err
+			}
+			if info, err := os.Stat(real); err != nil || info.IsDir() || seen[real] {
+				continue
+			}
+			seen[real] = true
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 队列满时的处理策略
+const (
+	OverflowBlock      = "block"       // 阻塞等待，不丢日志
+	OverflowDropOldest = "drop_oldest" // 丢弃队列中最早的日志
+	OverflowDropNewest = "drop_newest" // 丢弃当前写入的日志
+)
+
+// AsyncOptions 异步写入配置
+type AsyncOptions struct {
+	BufferSize    int           `yaml:"buffer_size"`    // 队列最多缓存的日志条数
+	FlushInterval time.Duration `yaml:"flush_interval"` // 定期把缓冲区刷新到底层writer的间隔
+	Overflow      string        `yaml:"overflow"`       // block/drop_oldest/drop_newest
+}
+
+const (
+	defaultAsyncBufferSize    = 8192
+	defaultAsyncFlushInterval = time.Second
+	asyncWriteBufferSize      = 256 * 1024
+)
+
+var errAsyncClosed = errors.New("async write syncer is closed")
+
+// AsyncWriteSyncer 异步的zapcore.WriteSyncer
+// Write只把日志放入有界环形队列，后台goroutine批量写入底层writer，Sync会等待队列清空
+type AsyncWriteSyncer struct {
+	ws       zapcore.WriteSyncer
+	overflow string
+	onDrop   func(dropped uint64)
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ring    [][]byte
+	head    int
+	size    int
+	busy    bool   // 后台goroutine正在写入取出的日志
+	pending uint64 // 上次恢复之后丢弃的条数
+	closed  bool
+
+	dropped atomic.Uint64
+
+	wmu sync.Mutex // 保护bw和ws
+	bw  *bufio.Writer
+
+	done chan struct{}
+	stop chan struct{}
+}
+
+// NewAsyncWriteSyncer 创建异步writer
+// onDrop 在丢弃日志后队列恢复写入时调用，参数为期间丢弃的条数，可以为nil
+func NewAsyncWriteSyncer(ws zapcore.WriteSyncer, opts AsyncOptions, onDrop func(dropped uint64)) (*AsyncWriteSyncer, error) {
+	switch opts.Overflow {
+	case "":
+		opts.Overflow = OverflowBlock
+	case OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+	default:
+		return nil, fmt.Errorf("unknown overflow policy %q", opts.Overflow)
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultAsyncBufferSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultAsyncFlushInterval
+	}
+
+	w := &AsyncWriteSyncer{
+		ws:       ws,
+		overflow: opts.Overflow,
+		onDrop:   onDrop,
+		ring:     make([][]byte, opts.BufferSize),
+		bw:       bufio.NewWriterSize(ws, asyncWriteBufferSize),
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+
+	go w.run()
+	go w.flushLoop(opts.FlushInterval)
+	return w, nil
+}
+
+// Write 把日志放入队列，zap会复用p，所以这里需要复制
+func (w *AsyncWriteSyncer) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errAsyncClosed
+	}
+
+	if w.size == len(w.ring) {
+		switch w.overflow {
+		case OverflowDropNewest:
+			w.drop()
+			return len(p), nil
+		case OverflowDropOldest:
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+			w.size--
+			w.drop()
+		default:
+			for w.size == len(w.ring) && !w.closed {
+				w.cond.Wait()
+			}
+			if w.closed {
+				return 0, errAsyncClosed
+			}
+		}
+	}
+
+	w.ring[(w.head+w.size)%len(w.ring)] = b
+	w.size++
+	w.cond.Broadcast()
+	return len(p), nil
+}
+
+// drop 记录丢弃的日志，调用方持有mu
+func (w *AsyncWriteSyncer) drop() {
+	w.pending++
+	w.dropped.Add(1)
+}
+
+// Dropped 返回累计丢弃的日志条数
+func (w *AsyncWriteSyncer) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Sync 等待队列中的日志全部写入，然后刷新底层writer
+func (w *AsyncWriteSyncer) Sync() error {
+	w.mu.Lock()
+	for (w.size > 0 || w.busy) && !w.closed {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	return w.ws.Sync()
+}
+
+// Close 写完队列中剩余的日志后停止后台goroutine
+func (w *AsyncWriteSyncer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+	close(w.stop)
+
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	return w.ws.Sync()
+}
+
+// run 后台goroutine，每次取出队列中的全部日志写入缓冲区
+func (w *AsyncWriteSyncer) run() {
+	defer close(w.done)
+
+	batch := make([][]byte, 0, len(w.ring))
+	for {
+		w.mu.Lock()
+		for w.size == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.size == 0 && w.closed {
+			w.mu.Unlock()
+			return
+		}
+
+		batch = batch[:0]
+		for w.size > 0 {
+			batch = append(batch, w.ring[w.head])
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+			w.size--
+		}
+		dropped := w.pending
+		w.pending = 0
+		w.busy = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		w.wmu.Lock()
+		for _, b := range batch {
+			// 写入失败时没有更好的处理方式，与zap同步写入时一样忽略
+			_, _ = w.bw.Write(b)
+		}
+		w.wmu.Unlock()
+
+		// 队列恢复后报告丢弃的条数，onDrop可能再次写入本writer，所以不能在这里同步调用
+		if dropped > 0 && w.onDrop != nil {
+			go w.onDrop(dropped)
+		}
+
+		w.mu.Lock()
+		w.busy = false
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+// flushLoop 定期刷新缓冲区
+func (w *AsyncWriteSyncer) flushLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.wmu.Lock()
+			_ = w.bw.Flush()
+			w.wmu.Unlock()
+		case <-w.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// lockedBuffer 并发安全的输出
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Sync() error { return nil }
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor 等待后台goroutine达到预期的状态
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// fillQueue 让后台goroutine阻塞在写入上，再写满长度为2的队列
+// 返回时后台goroutine持有第一条日志，队列中是第二、三条，调用方需要调用release
+func fillQueue(t *testing.T, w *AsyncWriteSyncer) (release func()) {
+	t.Helper()
+	w.wmu.Lock()
+	w.Write([]byte("0\n"))
+	waitFor(t, "the first entry to be taken", func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.busy && w.size == 0
+	})
+	w.Write([]byte("1\n"))
+	w.Write([]byte("2\n"))
+	return w.wmu.Unlock
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	tests := []struct {
+		overflow string
+		want     string
+		dropped  uint64
+	}{
+		{OverflowDropNewest, "0\n1\n2\n", 1},
+		{OverflowDropOldest, "0\n2\n3\n", 1},
+		{OverflowBlock, "0\n1\n2\n3\n", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.overflow, func(t *testing.T) {
+			out := &lockedBuffer{}
+			drops := make(chan uint64, 1)
+			w, err := NewAsyncWriteSyncer(out, AsyncOptions{BufferSize: 2, Overflow: tt.overflow}, func(n uint64) { drops <- n })
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			release := fillQueue(t, w)
+			written := make(chan struct{})
+			go func() {
+				w.Write([]byte("3\n"))
+				close(written)
+			}()
+
+			if tt.overflow == OverflowBlock {
+				select {
+				case <-written:
+					t.Fatal("Write did not block on a full queue")
+				case <-time.After(50 * time.Millisecond):
+				}
+			} else {
+				<-written
+			}
+			release()
+			<-written
+
+			if err := w.Sync(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Fatalf("output = %q, want %q", got, tt.want)
+			}
+			if got := w.Dropped(); got != tt.dropped {
+				t.Fatalf("Dropped() = %d, want %d", got, tt.dropped)
+			}
+
+			// 队列恢复写入后报告期间丢弃的条数
+			if tt.dropped > 0 {
+				select {
+				case n := <-drops:
+					if n != tt.dropped {
+						t.Fatalf("onDrop(%d), want %d", n, tt.dropped)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatal("onDrop was not called")
+				}
+			} else {
+				select {
+				case n := <-drops:
+					t.Fatalf("unexpected onDrop(%d)", n)
+				default:
+				}
+			}
+		})
+	}
+}
+
+func TestAsyncSyncAndClose(t *testing.T) {
+	out := &lockedBuffer{}
+	// 刷新间隔足够长，输出只能来自Sync和Close
+	w, err := NewAsyncWriteSyncer(out, AsyncOptions{BufferSize: 16, FlushInterval: time.Hour}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Write([]byte("a\n"))
+	w.Write([]byte("b\n"))
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "a\nb\n" {
+		t.Fatalf("after Sync = %q", got)
+	}
+
+	// 后台goroutine阻塞时，队列中的日志在Close时写出
+	w.wmu.Lock()
+	for _, line := range []string{"c\n", "d\n", "e\n"} {
+		w.Write([]byte(line))
+	}
+	closed := make(chan error)
+	go func() { closed <- w.Close() }()
+	w.wmu.Unlock()
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "a\nb\nc\nd\ne\n" {
+		t.Fatalf("after Close = %q", got)
+	}
+	if _, err := w.Write([]byte("f\n")); !errors.Is(err, errAsyncClosed) {
+		t.Fatalf("Write after Close = %v", err)
+	}
+	if strings.Contains(out.String(), "f") {
+		t.Fatal("entry written after Close")
+	}
+}
+
+// newBenchLogger 创建与demo1中"performance test"相同写法的logger，输出到临时目录的lumberjack文件
+func newBenchLogger(b *testing.B, async *AsyncOptions) (*zap.Logger, func()) {
+	b.Helper()
+
+	file := &lumberjack.Logger{Filename: filepath.Join(b.TempDir(), "app.log"), MaxSize: 100}
+	var ws zapcore.WriteSyncer = zapcore.AddSync(file)
+	closer := multiCloser{file}
+	if async != nil {
+		w, err := NewAsyncWriteSyncer(ws, *async, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ws = w
+		closer = multiCloser{w, file}
+	}
+
+	encoder, _ := newEncoder("json", false, "2006-01-02 15:04:05.000")
+	logger := zap.New(zapcore.NewCore(encoder, ws, zapcore.DebugLevel))
+	return logger, func() {
+		logger.Sync()
+		closer.Close()
+	}
+}
+
+func benchmarkLogger(b *testing.B, async *AsyncOptions) {
+	logger, closeLogger := newBenchLogger(b, async)
+	defer closeLogger()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			logger.Info("performance test",
+				zap.Int("iteration", i),
+				zap.Time("timestamp", time.Now()),
+			)
+			i++
+		}
+	})
+	b.StopTimer()
+}
+
+// BenchmarkSyncFile 当前的同步写入方式
+func BenchmarkSyncFile(b *testing.B) {
+	benchmarkLogger(b, nil)
+}
+
+func BenchmarkAsyncFileBlock(b *testing.B) {
+	benchmarkLogger(b, &AsyncOptions{Overflow: OverflowBlock})
+}
+
+func BenchmarkAsyncFileDropOldest(b *testing.B) {
+	benchmarkLogger(b, &AsyncOptions{Overflow: OverflowDropOldest})
+}
+
+func BenchmarkAsyncFileDropNewest(b *testing.B) {
+	benchmarkLogger(b, &AsyncOptions{Overflow: OverflowDropNewest})
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"01-zap/logx"
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// 请求级别字段的key
+const (
+	RequestIDKey = "requestID"
+	UserIDKey    = "userID"
+	TraceIDKey   = "traceID"
+	SpanIDKey    = "spanID"
+)
+
+// WithContext 在ctx中已有的logger基础上追加字段，返回携带新logger的ctx
+// logger通过logx.WithZap保存，logx.NewZap创建的Logger输出时同样使用它
+//
+//	ctx = config.WithContext(ctx, zap.String("module", "user"))
+//	config.FromContext(ctx).Info("processing user request")
+func WithContext(ctx context.Context, fields ...zap.Field) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return logx.WithZap(ctx, FromContext(ctx).With(fields...))
+}
+
+// FromContext 返回ctx中携带的logger，没有时返回全局Logger
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := logx.ZapFromContext(ctx); ok {
+		return logger
+	}
+	if Logger != nil {
+		return Logger
+	}
+	return zap.NewNop()
+}
+
+// SugarFromContext 返回ctx中携带的SugarLogger
+func SugarFromContext(ctx context.Context) *zap.SugaredLogger {
+	return FromContext(ctx).Sugar()
+}
+
+// WithRequestID 在ctx的logger中添加请求ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return WithContext(ctx, zap.String(RequestIDKey, requestID))
+}
+
+// WithUserID 在ctx的logger中添加用户ID
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return WithContext(ctx, zap.String(UserIDKey, userID))
+}
+
+// WithTrace 在ctx的logger中添加链路追踪的trace ID和span ID
+func WithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	return WithContext(ctx, zap.String(TraceIDKey, traceID), zap.String(SpanIDKey, spanID))
+}
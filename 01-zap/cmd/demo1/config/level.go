@@ -0,0 +1,223 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ModuleKey 子logger通过该字段区分模块，如 Logger.With(zap.String("module", "user"))
+const ModuleKey = "module"
+
+// Levels 全局Logger的级别，InitLogger之后可以在运行时修改
+var Levels = NewLevelRegistry(zapcore.DebugLevel)
+
+// LevelRegistry 管理全局日志级别和按模块设置的日志级别
+type LevelRegistry struct {
+	global zap.AtomicLevel
+
+	mu      sync.RWMutex
+	modules map[string]zap.AtomicLevel
+}
+
+// NewLevelRegistry 创建级别注册表
+func NewLevelRegistry(level zapcore.Level) *LevelRegistry {
+	return &LevelRegistry{
+		global:  zap.NewAtomicLevelAt(level),
+		modules: make(map[string]zap.AtomicLevel),
+	}
+}
+
+// Global 返回全局级别
+func (r *LevelRegistry) Global() zap.AtomicLevel {
+	return r.global
+}
+
+// SetLevel 设置全局级别
+func (r *LevelRegistry) SetLevel(level zapcore.Level) {
+	r.global.SetLevel(level)
+}
+
+// SetModuleLevel 设置某个模块的级别，该模块不再跟随全局级别
+func (r *LevelRegistry) SetModuleLevel(module string, level zapcore.Level) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if lvl, ok := r.modules[module]; ok {
+		lvl.SetLevel(level)
+		return
+	}
+	r.modules[module] = zap.NewAtomicLevelAt(level)
+}
+
+// ResetModuleLevel 删除模块级别，该模块重新跟随全局级别
+func (r *LevelRegistry) ResetModuleLevel(module string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.modules, module)
+}
+
+// ModuleLevel 返回模块当前生效的级别
+func (r *LevelRegistry) ModuleLevel(module string) zapcore.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if lvl, ok := r.modules[module]; ok {
+		return lvl.Level()
+	}
+	return r.global.Level()
+}
+
+// ModuleLevels 返回所有单独设置了级别的模块
+func (r *LevelRegistry) ModuleLevels() map[string]zapcore.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	levels := make(map[string]zapcore.Level, len(r.modules))
+	for module, lvl := range r.modules {
+		levels[module] = lvl.Level()
+	}
+	return levels
+}
+
+// Enabled 判断模块是否输出该级别的日志，module为空时使用全局级别
+func (r *LevelRegistry) Enabled(module string, level zapcore.Level) bool {
+	return r.ModuleLevel(module).Enabled(level)
+}
+
+// levelPayload 管理接口的请求和响应
+type levelPayload struct {
+	Level   string            `json:"level,omitempty"`
+	Module  string            `json:"module,omitempty"`
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+// ServeHTTP 日志级别管理接口
+//
+//	GET    /log/level               查看全局级别和所有模块级别
+//	GET    /log/level?module=user   查看模块级别
+//	PUT    /log/level               {"level":"debug"} 修改全局级别
+//	PUT    /log/level?module=user   {"level":"debug"} 修改模块级别
+//	DELETE /log/level?module=user   模块重新跟随全局级别
+func (r *LevelRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	module := req.URL.Query().Get(ModuleKey)
+
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var payload levelPayload
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			writeLevelError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		level, err := zapcore.ParseLevel(payload.Level)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		if module == "" {
+			module = payload.Module
+		}
+		if module == "" {
+			r.SetLevel(level)
+		} else {
+			r.SetModuleLevel(module, level)
+		}
+	case http.MethodDelete:
+		if module == "" {
+			writeLevelError(w, http.StatusBadRequest, fmt.Errorf("missing %q query parameter", ModuleKey))
+			return
+		}
+		r.ResetModuleLevel(module)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(r.payload(module))
+}
+
+// payload 生成当前级别的响应内容
+func (r *LevelRegistry) payload(module string) levelPayload {
+	if module != "" {
+		return levelPayload{Module: module, Level: r.ModuleLevel(module).String()}
+	}
+
+	modules := r.ModuleLevels()
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	payload := levelPayload{Level: r.global.Level().String()}
+	if len(names) > 0 {
+		payload.Modules = make(map[string]string, len(names))
+		for _, name := range names {
+			payload.Modules[name] = modules[name].String()
+		}
+	}
+	return payload
+}
+
+func writeLevelError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// LevelHandler 返回全局Logger的级别管理接口，挂到管理端口上使用
+//
+//	http.Handle("/log/level", config.LevelHandler())
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		Levels.ServeHTTP(w, req)
+	})
+}
+
+// moduleCore 根据module字段选择级别的Core
+// 底层Core的级别由LevelRegistry决定，With中遇到module字段时切换到对应模块的级别
+type moduleCore struct {
+	zapcore.Core
+	levels *LevelRegistry
+	module string
+}
+
+// newModuleCore 包装底层Core，底层Core自身的级别不再生效
+func newModuleCore(core zapcore.Core, levels *LevelRegistry) zapcore.Core {
+	return &moduleCore{Core: core, levels: levels}
+}
+
+func (c *moduleCore) Enabled(level zapcore.Level) bool {
+	return c.levels.Enabled(c.module, level)
+}
+
+// Level 实现zapcore.LevelOf使用的接口
+func (c *moduleCore) Level() zapcore.Level {
+	return c.levels.ModuleLevel(c.module)
+}
+
+func (c *moduleCore) With(fields []zapcore.Field) zapcore.Core {
+	module := c.module
+	for _, f := range fields {
+		if f.Key == ModuleKey && f.Type == zapcore.StringType {
+			module = f.String
+		}
+	}
+	return &moduleCore{Core: c.Core.With(fields), levels: c.levels, module: module}
+}
+
+// Check 先按模块级别过滤，再交给底层Core按各自的级别范围判断
+func (c *moduleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,48 @@
+//go:build !windows
+
+package config
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WatchLevelSignals 通过信号切换全局级别，适用于无法访问管理端口的机器
+//
+//	kill -USR1 <pid>  切换到Debug级别
+//	kill -USR2 <pid>  恢复到切换前的级别
+//
+// 返回的函数用于停止监听
+func WatchLevelSignals(levels *LevelRegistry) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	done := make(chan struct{})
+	go func() {
+		previous := levels.Global().Level()
+		for {
+			select {
+			case sig := <-sigs:
+				switch sig {
+				case syscall.SIGUSR1:
+					if current := levels.Global().Level(); current != zapcore.DebugLevel {
+						previous = current
+					}
+					levels.SetLevel(zapcore.DebugLevel)
+				case syscall.SIGUSR2:
+					levels.SetLevel(previous)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package config
+
+// WatchLevelSignals Windows没有SIGUSR1/SIGUSR2，请使用LevelHandler修改级别
+func WatchLevelSignals(levels *LevelRegistry) (stop func()) {
+	return func() {}
+}
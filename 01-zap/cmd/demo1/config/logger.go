@@ -1,58 +1,183 @@
 package config
 
 import (
+	"01-zap/sampling"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
-	"os"
-	"path/filepath"
 )
 
 var Logger *zap.Logger
 var SugarLogger *zap.SugaredLogger
 
+// cleanup 关闭当前Logger打开的文件
+var cleanup = func() error { return nil }
+
 // InitLogger 初始化Logger
+// 配置文件路径通过环境变量LOG_CONFIG指定，未指定时使用默认配置
 func InitLogger() {
-	// 设置日志文件路径
-	logFile := filepath.Join("logs", "app.log")
-
-	// 设置输出到文件
-	fileWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   logFile, // 日志文件路径
-		MaxSize:    10,      // 每个日志文件保存的最大尺寸 单位：M
-		MaxBackups: 30,      // 日志文件最多保存多少个备份
-		MaxAge:     7,       // 文件最多保存多少天
-		Compress:   true,    // 是否压缩
-	})
+	opts, err := LoadOptions(os.Getenv("LOG_CONFIG"))
+	if err != nil {
+		panic("加载日志配置失败: " + err.Error())
+	}
+	if err := InitLoggerWithOptions(opts); err != nil {
+		panic("初始化logger失败: " + err.Error())
+	}
+}
+
+// InitLoggerWithOptions 使用指定配置初始化全局Logger
+func InitLoggerWithOptions(opts *LoggerOptions) error {
+	logger, levels, closer, err := build(opts)
+	if err != nil {
+		return err
+	}
+
+	// 关闭上一次初始化打开的文件
+	_ = cleanup()
+
+	Logger = logger
+	SugarLogger = Logger.Sugar()
+	Levels = levels
+	cleanup = closer
+	return nil
+}
+
+// ReplaceLogger 替换全局Logger和SugarLogger，返回的函数用于恢复原来的值
+func ReplaceLogger(logger *zap.Logger) func() {
+	oldLogger, oldSugar := Logger, SugarLogger
+	Logger, SugarLogger = logger, logger.Sugar()
+	return func() {
+		Logger, SugarLogger = oldLogger, oldSugar
+	}
+}
+
+// NewLogger 根据配置创建Logger
+// 返回的函数用于退出前刷新缓冲区并关闭日志文件
+func NewLogger(opts *LoggerOptions) (*zap.Logger, func() error, error) {
+	logger, _, closer, err := build(opts)
+	return logger, closer, err
+}
+
+// build 创建Logger及其级别注册表
+func build(opts *LoggerOptions) (*zap.Logger, *LevelRegistry, func() error, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
 
 	// 设置日志级别
-	atomicLevel := zap.NewAtomicLevel()
-	atomicLevel.SetLevel(zapcore.DebugLevel)
-
-	// 设置开发模式
-	developmentCfg := zap.NewDevelopmentEncoderConfig()
-	developmentCfg.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05.000")
-	developmentCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
-
-	// 创建核心配置
-	core := zapcore.NewTee(
-		// 同时输出到控制台和文件
-		zapcore.NewCore(
-			zapcore.NewConsoleEncoder(developmentCfg),
-			zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout), fileWriter),
-			atomicLevel,
-		),
-	)
+	levels, err := newLevels(opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 敏感字段脱敏
+	var redactor *Redactor
+	if opts.Redact != nil {
+		if redactor, err = NewRedactor(*opts.Redact); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	// 每个输出目标单独创建Core，有各自的级别范围和编码器
+	// 异步队列丢弃日志后，恢复时通过logger本身记录丢弃的条数
+	var logger *zap.Logger
+	onDrop := func(output string, dropped uint64) {
+		if logger != nil {
+			logger.Warn("async log queue overflowed",
+				zap.String("output", output),
+				zap.Uint64("dropped", dropped),
+			)
+		}
+	}
+	cores, closers, err := newSinkCores(opts, redactor, onDrop)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 创建核心配置，级别由levels按模块判断
+	core := newModuleCore(zapcore.NewTee(cores...), levels)
+
+	zapOpts := []zap.Option{}
+	if opts.Caller {
+		zapOpts = append(zapOpts, zap.AddCaller()) // 添加调用者信息
+	}
+	if opts.StacktraceLevel != "" {
+		stackLevel, err := zapcore.ParseLevel(opts.StacktraceLevel)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, nil, fmt.Errorf("invalid stacktrace level %q: %w", opts.StacktraceLevel, err)
+		}
+		zapOpts = append(zapOpts, zap.AddStacktrace(stackLevel)) // 该级别及以上添加堆栈信息
+	}
+	if len(opts.InitialFields) > 0 {
+		zapOpts = append(zapOpts, zap.Fields(initialFields(opts.InitialFields)...))
+	}
+	// 放在初始化字段之后，汇总日志同样带有初始化字段；退出时先输出最后一次汇总再关闭文件
+	if opts.Sampling != nil {
+		zapOpts = append(zapOpts, zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			c, sampler := sampling.NewCore(c, *opts.Sampling)
+			closers = append([]io.Closer{sampler}, closers...)
+			return c
+		}))
+	}
 
 	// 创建logger
-	Logger = zap.New(core,
-		zap.AddCaller(), // 添加调用者信息
-		// zap.AddCallerSkip(1),                  // 跳过一层调用堆栈
-		zap.AddStacktrace(zapcore.ErrorLevel), // Error级别及以上添加堆栈信息
-	)
+	logger = zap.New(core, zapOpts...)
 
-	// 创建SugarLogger
-	SugarLogger = Logger.Sugar()
+	closer := func() error {
+		_ = logger.Sync()
+		return closeAll(closers)
+	}
+	return logger, levels, closer, nil
+}
+
+// newLevels 根据配置创建全局级别和模块级别
+func newLevels(opts *LoggerOptions) (*LevelRegistry, error) {
+	level, err := zapcore.ParseLevel(opts.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", opts.Level, err)
+	}
+
+	levels := NewLevelRegistry(level)
+	for module, text := range opts.ModuleLevels {
+		moduleLevel, err := zapcore.ParseLevel(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level %q for module %q: %w", text, module, err)
+		}
+		levels.SetModuleLevel(module, moduleLevel)
+	}
+	return levels, nil
+}
+
+// initialFields 按key排序转换初始化字段，保证输出顺序稳定
+func initialFields(m map[string]any) []zap.Field {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, zap.Any(k, m[k]))
+	}
+	return fields
+}
+
+// closeAll 关闭所有文件并合并错误
+func closeAll(closers []io.Closer) error {
+	var errs []error
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // Sync 同步日志
@@ -60,3 +185,8 @@ func Sync() {
 	Logger.Sync()
 	SugarLogger.Sync()
 }
+
+// Close 刷新缓冲区并关闭日志文件
+func Close() error {
+	return cleanup()
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"01-zap/sampling"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoggerOptions 日志配置，可以从YAML文件和环境变量加载
+type LoggerOptions struct {
+	Level           string            `yaml:"level"`            // 日志级别 debug/info/warn/error
+	ModuleLevels    map[string]string `yaml:"module_levels"`    // 按模块设置的级别，key为module字段的值
+	Encoding        string            `yaml:"encoding"`         // 输出格式 console 或 json
+	Outputs         []string          `yaml:"outputs"`          // 输出目标 stdout/stderr/文件路径，设置后忽略Sinks
+	Sinks           []SinkOptions     `yaml:"sinks"`            // 按级别拆分的输出目标
+	TimeLayout      string            `yaml:"time_layout"`      // 时间格式
+	Caller          bool              `yaml:"caller"`           // 是否添加调用者信息
+	StacktraceLevel string            `yaml:"stacktrace_level"` // 该级别及以上添加堆栈信息
+	Rotation        RotationOptions   `yaml:"rotation"`         // 文件轮转策略
+	Sampling        *SamplingOptions  `yaml:"sampling"`         // 采样配置，为空则不采样
+	InitialFields   map[string]any    `yaml:"initial_fields"`   // 初始化字段，如：serviceName
+	Redact          *RedactOptions    `yaml:"redact"`           // 敏感字段脱敏，为空则不脱敏
+}
+
+// RotationOptions 文件轮转配置
+// 只设置大小时使用lumberjack，设置了Pattern或RotationTime时使用rotate包，按大小和时间轮转
+type RotationOptions struct {
+	MaxSize      int           `yaml:"max_size"`      // 每个日志文件保存的最大尺寸 单位：M
+	MaxBackups   int           `yaml:"max_backups"`   // 日志文件最多保存多少个备份
+	MaxAge       int           `yaml:"max_age"`       // 文件最多保存多少天
+	Compress     bool          `yaml:"compress"`      // 是否压缩
+	Pattern      string        `yaml:"pattern"`       // strftime格式的文件名，如 logs/app.log.%Y%m%d，为空时使用 输出路径.%Y%m%d
+	RotationTime time.Duration `yaml:"rotation_time"` // 按时间轮转的间隔，如 24h，为空时按Pattern中最小的时间格式推导
+	Link         bool          `yaml:"link"`          // 在输出路径上创建指向当前文件的软链
+}
+
+// timeBased 是否使用按时间轮转
+func (r RotationOptions) timeBased() bool {
+	return r.Pattern != "" || r.RotationTime > 0
+}
+
+// SamplingOptions 采样配置，每个Tick内同一条消息前Initial条全部输出，之后每Thereafter条输出一条
+// 可以再按字段值限流，被丢弃的条数定期以 "suppressed N similar entries" 输出
+type SamplingOptions = sampling.Options
+
+// DefaultOptions 默认配置
+// 控制台彩色输出，logs/app.log 记录全部日志，logs/error.log 单独记录Warn及以上，文件使用JSON格式
+func DefaultOptions() *LoggerOptions {
+	return &LoggerOptions{
+		Level:    "debug",
+		Encoding: "console",
+		Sinks: []SinkOptions{
+			{Output: "stdout", Encoding: "console", Color: true},
+			{Output: "logs/app.log", Encoding: "json"},
+			{
+				Output:   "logs/error.log",
+				Encoding: "json",
+				MinLevel: "warn",
+				Rotation: &RotationOptions{MaxSize: 10, MaxBackups: 90, MaxAge: 30, Compress: true},
+			},
+		},
+		TimeLayout:      "2006-01-02 15:04:05.000",
+		Caller:          true,
+		StacktraceLevel: "error",
+		Rotation: RotationOptions{
+			MaxSize:    10,
+			MaxBackups: 30,
+			MaxAge:     7,
+			Compress:   true,
+		},
+		Redact: &RedactOptions{
+			Keys: []string{"password", "passwd", "secret", "token", "authorization"},
+			Mode: RedactMask,
+		},
+	}
+}
+
+// LoadOptions 加载日志配置
+// 先使用默认配置，再用YAML文件覆盖（path为空则跳过），最后用LOG_开头的环境变量覆盖
+func LoadOptions(path string) (*LoggerOptions, error) {
+	opts := DefaultOptions()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read logger config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, opts); err != nil {
+			return nil, fmt.Errorf("parse logger config %s: %w", path, err)
+		}
+	}
+
+	if err := opts.applyEnv(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// applyEnv 使用环境变量覆盖配置
+func (o *LoggerOptions) applyEnv() error {
+	if v, ok := os.LookupEnv("LOG_LEVEL"); ok {
+		o.Level = v
+	}
+	if v, ok := os.LookupEnv("LOG_ENCODING"); ok {
+		o.Encoding = v
+	}
+	if v, ok := os.LookupEnv("LOG_OUTPUTS"); ok {
+		o.Outputs = splitList(v)
+	}
+	if v, ok := os.LookupEnv("LOG_REDACT_HASH_KEY"); ok && o.Redact != nil {
+		o.Redact.HashKey = v
+	}
+
+	ints := map[string]*int{
+		"LOG_ROTATION_MAX_SIZE":    &o.Rotation.MaxSize,
+		"LOG_ROTATION_MAX_BACKUPS": &o.Rotation.MaxBackups,
+		"LOG_ROTATION_MAX_AGE":     &o.Rotation.MaxAge,
+	}
+	for key, dst := range ints {
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		*dst = n
+	}
+
+	if v, ok := os.LookupEnv("LOG_ROTATION_COMPRESS"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid LOG_ROTATION_COMPRESS: %w", err)
+		}
+		o.Rotation.Compress = b
+	}
+	return nil
+}
+
+// splitList 按逗号拆分并去掉空项
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
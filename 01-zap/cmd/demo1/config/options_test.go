@@ -0,0 +1,205 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// optionEnvs LoadOptions读取的环境变量
+var optionEnvs = []string{
+	"LOG_LEVEL", "LOG_ENCODING", "LOG_OUTPUTS", "LOG_REDACT_HASH_KEY",
+	"LOG_ROTATION_MAX_SIZE", "LOG_ROTATION_MAX_BACKUPS", "LOG_ROTATION_MAX_AGE", "LOG_ROTATION_COMPRESS",
+}
+
+// clearOptionEnvs 清除运行环境中的LOG_变量，测试结束后恢复
+func clearOptionEnvs(t *testing.T) {
+	t.Helper()
+	for _, key := range optionEnvs {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+// writeConfig 把YAML写入临时文件，返回文件路径
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "logger.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadOptionsDefaults(t *testing.T) {
+	clearOptionEnvs(t)
+
+	opts, err := LoadOptions("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opts, DefaultOptions()) {
+		t.Fatalf("LoadOptions(\"\") = %+v, want defaults", opts)
+	}
+	if opts.Level != "debug" || opts.Encoding != "console" || len(opts.Sinks) != 3 || opts.Rotation.MaxSize != 10 {
+		t.Fatalf("unexpected defaults %+v", opts)
+	}
+}
+
+func TestLoadOptionsFile(t *testing.T) {
+	clearOptionEnvs(t)
+	path := writeConfig(t, `
+level: warn
+encoding: json
+outputs: [stdout, logs/test.log]
+rotation:
+  max_size: 50
+  compress: false
+`)
+
+	opts, err := LoadOptions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Level != "warn" || opts.Encoding != "json" {
+		t.Errorf("Level = %q, Encoding = %q", opts.Level, opts.Encoding)
+	}
+	if !reflect.DeepEqual(opts.Outputs, []string{"stdout", "logs/test.log"}) {
+		t.Errorf("Outputs = %q", opts.Outputs)
+	}
+	// 文件中没有设置的字段保留默认值
+	want := RotationOptions{MaxSize: 50, MaxBackups: 30, MaxAge: 7, Compress: false}
+	if opts.Rotation != want {
+		t.Errorf("Rotation = %+v, want %+v", opts.Rotation, want)
+	}
+	if opts.TimeLayout != DefaultOptions().TimeLayout || !opts.Caller {
+		t.Errorf("defaults not kept: %+v", opts)
+	}
+}
+
+func TestLoadOptionsEnvOverridesFile(t *testing.T) {
+	clearOptionEnvs(t)
+	path := writeConfig(t, `
+level: warn
+encoding: json
+outputs: [stdout]
+rotation:
+  max_size: 50
+  max_backups: 5
+  max_age: 3
+  compress: false
+redact:
+  keys: [password]
+  mode: hash
+  hash_key: from-file
+`)
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("LOG_ENCODING", "console")
+	t.Setenv("LOG_OUTPUTS", " stderr, ,logs/env.log ")
+	t.Setenv("LOG_ROTATION_MAX_SIZE", "100")
+	t.Setenv("LOG_ROTATION_MAX_BACKUPS", "9")
+	t.Setenv("LOG_ROTATION_MAX_AGE", "14")
+	t.Setenv("LOG_ROTATION_COMPRESS", "true")
+	t.Setenv("LOG_REDACT_HASH_KEY", "from-env")
+
+	opts, err := LoadOptions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Level != "error" || opts.Encoding != "console" {
+		t.Errorf("Level = %q, Encoding = %q", opts.Level, opts.Encoding)
+	}
+	if !reflect.DeepEqual(opts.Outputs, []string{"stderr", "logs/env.log"}) {
+		t.Errorf("Outputs = %q", opts.Outputs)
+	}
+	want := RotationOptions{MaxSize: 100, MaxBackups: 9, MaxAge: 14, Compress: true}
+	if opts.Rotation != want {
+		t.Errorf("Rotation = %+v, want %+v", opts.Rotation, want)
+	}
+	if opts.Redact == nil || opts.Redact.HashKey != "from-env" || opts.Redact.Mode != RedactHash {
+		t.Errorf("Redact = %+v", opts.Redact)
+	}
+}
+
+func TestLoadOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path func(t *testing.T) string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "missing file",
+			path: func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.yaml") },
+			want: "read logger config",
+		},
+		{
+			name: "invalid yaml",
+			path: func(t *testing.T) string { return writeConfig(t, "level: [debug") },
+			want: "parse logger config",
+		},
+		{
+			name: "invalid max size",
+			env:  map[string]string{"LOG_ROTATION_MAX_SIZE": "10M"},
+			want: "invalid LOG_ROTATION_MAX_SIZE",
+		},
+		{
+			name: "invalid max age",
+			env:  map[string]string{"LOG_ROTATION_MAX_AGE": "a week"},
+			want: "invalid LOG_ROTATION_MAX_AGE",
+		},
+		{
+			name: "invalid compress",
+			env:  map[string]string{"LOG_ROTATION_COMPRESS": "maybe"},
+			want: "invalid LOG_ROTATION_COMPRESS",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearOptionEnvs(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := ""
+			if tt.path != nil {
+				path = tt.path(t)
+			}
+			opts, err := LoadOptions(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("LoadOptions() = %+v, %v, want error containing %q", opts, err, tt.want)
+			}
+		})
+	}
+}
+
+// 级别和编码在LoadOptions中不校验，创建Logger时返回错误
+func TestNewLoggerInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"level", map[string]string{"LOG_LEVEL": "loud"}, `invalid log level "loud"`},
+		{"encoding", map[string]string{"LOG_ENCODING": "xml"}, `unknown log encoding "xml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearOptionEnvs(t)
+			// 只输出到控制台，不创建日志文件
+			t.Setenv("LOG_OUTPUTS", "stderr")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			opts, err := LoadOptions("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			logger, _, err := NewLogger(opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("NewLogger() = %v, %v, want error containing %q", logger, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 脱敏方式
+const (
+	RedactMask = "mask" // 替换为固定的掩码
+	RedactHash = "hash" // 替换为带密钥的HMAC-SHA256，相同的值仍然可以关联
+)
+
+// RedactTag 结构体字段标记为 `log:"redact"` 时脱敏
+const RedactTag = "log"
+
+const (
+	redactMask     = "******"
+	redactMaxDepth = 10
+)
+
+// RedactOptions 敏感字段脱敏配置，不同环境可以使用不同的配置文件
+type RedactOptions struct {
+	Keys          []string `yaml:"keys"`           // 按字段名脱敏，不区分大小写
+	Patterns      []string `yaml:"patterns"`       // 按正则匹配字段名脱敏
+	ValuePatterns []string `yaml:"value_patterns"` // 按正则匹配字符串值，只替换匹配的部分
+	Mode          string   `yaml:"mode"`           // mask 或 hash，默认 mask
+	// HashKey hash模式的HMAC密钥，必填，建议通过环境变量LOG_REDACT_HASH_KEY指定
+	// 不带密钥的摘要可以通过穷举手机号等取值范围小的值还原
+	HashKey string `yaml:"hash_key"`
+}
+
+// Redactor 根据配置对字段脱敏
+type Redactor struct {
+	keys     map[string]struct{}
+	patterns []*regexp.Regexp
+	values   []*regexp.Regexp
+	hashKey  []byte // 非空时使用hash模式
+}
+
+// NewRedactor 创建脱敏器
+func NewRedactor(opts RedactOptions) (*Redactor, error) {
+	r := &Redactor{keys: make(map[string]struct{}, len(opts.Keys))}
+
+	switch opts.Mode {
+	case RedactMask, "":
+	case RedactHash:
+		if opts.HashKey == "" {
+			return nil, fmt.Errorf("redact mode %q requires hash_key", RedactHash)
+		}
+		r.hashKey = []byte(opts.HashKey)
+	default:
+		return nil, fmt.Errorf("unknown redact mode %q", opts.Mode)
+	}
+
+	for _, key := range opts.Keys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+	}
+	for _, p := range opts.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, p := range opts.ValuePatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact value pattern %q: %w", p, err)
+		}
+		r.values = append(r.values, re)
+	}
+	return r, nil
+}
+
+// sensitive 判断字段名是否需要脱敏
+func (r *Redactor) sensitive(key string) bool {
+	if _, ok := r.keys[strings.ToLower(key)]; ok {
+		return true
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact 把敏感值替换为掩码或摘要
+func (r *Redactor) redact(value any) string {
+	if r.hashKey == nil {
+		return redactMask
+	}
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(fmt.Sprint(value)))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// redactString 替换字符串中匹配ValuePatterns的部分
+func (r *Redactor) redactString(s string) string {
+	for _, re := range r.values {
+		s = re.ReplaceAllStringFunc(s, func(m string) string { return r.redact(m) })
+	}
+	return s
+}
+
+// Fields 返回脱敏后的字段，不修改原切片
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = r.Field(f)
+	}
+	return out
+}
+
+// Field 对单个字段脱敏，嵌套的对象、map、切片和结构体会递归处理
+func (r *Redactor) Field(f zapcore.Field) zapcore.Field {
+	if r.sensitive(f.Key) {
+		return zap.String(f.Key, r.redact(fieldValue(f)))
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		if len(r.values) > 0 {
+			return zap.String(f.Key, r.redactString(f.String))
+		}
+	case zapcore.ReflectType:
+		return zap.Any(f.Key, r.value(reflect.ValueOf(f.Interface), 0))
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := f.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(enc); err != nil {
+			return f
+		}
+		obj := r.value(reflect.ValueOf(enc.Fields), 0)
+		if f.Type == zapcore.InlineMarshalerType {
+			return zap.Inline(obj.(zapcore.ObjectMarshaler))
+		}
+		return zap.Object(f.Key, obj.(zapcore.ObjectMarshaler))
+	case zapcore.ArrayMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := enc.AddArray(f.Key, f.Interface.(zapcore.ArrayMarshaler)); err != nil {
+			return f
+		}
+		return zap.Any(f.Key, r.value(reflect.ValueOf(enc.Fields[f.Key]), 0))
+	}
+	return f
+}
+
+// fieldValue 取出字段的原始值，用于计算摘要
+func fieldValue(f zapcore.Field) any {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return enc.Fields[f.Key]
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// value 递归处理值，结构体和map转为有序对象，切片转为数组
+func (r *Redactor) value(v reflect.Value, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > redactMaxDepth {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Pointer && v.Type().Implements(marshalerType) {
+			return v.Interface()
+		}
+		return r.value(v.Elem(), depth)
+	case reflect.String:
+		return r.redactString(v.String())
+	case reflect.Struct:
+		if v.Type() == timeType || v.Type().Implements(marshalerType) {
+			return v.Interface()
+		}
+		return r.structValue(v, depth)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		return r.mapValue(v, depth)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		arr := make(redactedArray, v.Len())
+		for i := range arr {
+			arr[i] = r.value(v.Index(i), depth+1)
+		}
+		return arr
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return nil
+}
+
+// structValue 按字段顺序处理结构体，字段名优先使用json标签
+func (r *Redactor) structValue(v reflect.Value, depth int) redactedObject {
+	t := v.Type()
+	obj := make(redactedObject, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		fv := v.Field(i)
+		if sf.Tag.Get(RedactTag) == "redact" || r.sensitive(name) {
+			obj = append(obj, redactedKV{name, r.redact(fv.Interface())})
+			continue
+		}
+		obj = append(obj, redactedKV{name, r.value(fv, depth+1)})
+	}
+	return obj
+}
+
+// mapValue 按key排序处理map，保证输出稳定
+func (r *Redactor) mapValue(v reflect.Value, depth int) redactedObject {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	obj := make(redactedObject, 0, len(keys))
+	for _, k := range keys {
+		name := k.String()
+		if r.sensitive(name) {
+			obj = append(obj, redactedKV{name, r.redact(v.MapIndex(k).Interface())})
+			continue
+		}
+		obj = append(obj, redactedKV{name, r.value(v.MapIndex(k), depth+1)})
+	}
+	return obj
+}
+
+type redactedKV struct {
+	key   string
+	value any
+}
+
+// redactedObject 脱敏后的对象，保留字段顺序
+type redactedObject []redactedKV
+
+func (o redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, kv := range o {
+		zap.Any(kv.key, kv.value).AddTo(enc)
+	}
+	return nil
+}
+
+// redactedArray 脱敏后的数组
+type redactedArray []any
+
+func (a redactedArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, v := range a {
+		switch v := v.(type) {
+		case zapcore.ObjectMarshaler:
+			if err := enc.AppendObject(v); err != nil {
+				return err
+			}
+		case zapcore.ArrayMarshaler:
+			if err := enc.AppendArray(v); err != nil {
+				return err
+			}
+		default:
+			if err := enc.AppendReflected(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// redactCore 写入前对字段脱敏的Core，包在NewTee中的每个Core外面即可，调用方无需修改
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+// NewRedactCore 包装Core，With和Write的字段都会先经过脱敏
+func NewRedactCore(core zapcore.Core, redactor *Redactor) zapcore.Core {
+	return &redactCore{Core: core, redactor: redactor}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.Fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.redactString(ent.Message)
+	return c.Core.Write(ent, c.redactor.Fields(fields))
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type account struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Phone    string `json:"phone" log:"redact"`
+	Skipped  string `json:"-"`
+}
+
+type order struct {
+	ID       int               `json:"id"`
+	Owner    account           `json:"owner"`
+	Items    []account         `json:"items"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// newRedactLogger 创建经过脱敏Core的logger，返回写入的日志
+func newRedactLogger(t *testing.T, opts RedactOptions) (*zap.Logger, *observer.ObservedLogs) {
+	t.Helper()
+	redactor, err := NewRedactor(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(NewRedactCore(core, redactor)), logs
+}
+
+func TestRedactFieldMatching(t *testing.T) {
+	logger, logs := newRedactLogger(t, RedactOptions{
+		Keys:          []string{"password", "Token"},
+		Patterns:      []string{"(?i)_key$"},
+		ValuePatterns: []string{`\d{4}-\d{4}-\d{4}-\d{4}`},
+	})
+
+	logger.With(zap.String("TOKEN", "t-1")).Info("card 1234-5678-9012-3456 charged",
+		zap.String("Password", "p@ss"),
+		zap.String("api_key", "k-1"),
+		zap.Int("password", 42),
+		zap.String("note", "paid by 1111-2222-3333-4444 today"),
+		zap.String("user", "alice"),
+	)
+	logger.Sugar().Infow("login", "password", "p@ss", "user", "bob")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if msg := entries[0].Message; msg != "card ****** charged" {
+		t.Errorf("message = %q", msg)
+	}
+	want := map[string]any{
+		"TOKEN":    redactMask,
+		"Password": redactMask,
+		"api_key":  redactMask,
+		"password": redactMask,
+		"note":     "paid by ****** today",
+		"user":     "alice",
+	}
+	if got := entries[0].ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+	want = map[string]any{"password": redactMask, "user": "bob"}
+	if got := entries[1].ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sugared fields = %v, want %v", got, want)
+	}
+}
+
+func TestRedactNested(t *testing.T) {
+	logger, logs := newRedactLogger(t, RedactOptions{Keys: []string{"password", "secret"}})
+
+	o := order{
+		ID:    7,
+		Owner: account{Name: "alice", Password: "p1", Phone: "13800000000", Skipped: "x"},
+		Items: []account{
+			{Name: "bob", Password: "p2", Phone: "13900000000"},
+		},
+		Metadata: map[string]string{"secret": "s", "source": "web"},
+	}
+	logger.Info("order", zap.Any("order", o), zap.Any("orders", []*order{&o}))
+	logger.Info("object",
+		zap.Object("obj", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("password", "p3")
+			enc.AddString("name", "carol")
+			return enc.AddObject("inner", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				enc.AddString("secret", "s2")
+				return nil
+			}))
+		})),
+		zap.Array("arr", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			return enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				enc.AddString("password", "p4")
+				return nil
+			}))
+		})),
+	)
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	wantOrder := map[string]any{
+		"id": int64(7),
+		"owner": map[string]any{
+			"name": "alice", "password": redactMask, "phone": redactMask,
+		},
+		"items": []any{
+			map[string]any{"name": "bob", "password": redactMask, "phone": redactMask},
+		},
+		"metadata": map[string]any{"secret": redactMask, "source": "web"},
+	}
+	fields := entries[0].ContextMap()
+	if got := fields["order"]; !reflect.DeepEqual(got, wantOrder) {
+		t.Errorf("order = %#v, want %#v", got, wantOrder)
+	}
+	if got := fields["orders"]; !reflect.DeepEqual(got, []any{wantOrder}) {
+		t.Errorf("orders = %#v", got)
+	}
+
+	fields = entries[1].ContextMap()
+	wantObj := map[string]any{
+		"password": redactMask,
+		"name":     "carol",
+		"inner":    map[string]any{"secret": redactMask},
+	}
+	if got := fields["obj"]; !reflect.DeepEqual(got, wantObj) {
+		t.Errorf("obj = %#v, want %#v", got, wantObj)
+	}
+	wantArr := []any{map[string]any{"password": redactMask}}
+	if got := fields["arr"]; !reflect.DeepEqual(got, wantArr) {
+		t.Errorf("arr = %#v, want %#v", got, wantArr)
+	}
+}
+
+func TestRedactHash(t *testing.T) {
+	if _, err := NewRedactor(RedactOptions{Mode: RedactHash}); err == nil {
+		t.Fatal("hash mode without hash_key: want error")
+	}
+
+	hash := func(key, value string) string {
+		t.Helper()
+		r, err := NewRedactor(RedactOptions{Mode: RedactHash, HashKey: key, Keys: []string{"phone"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.Field(zap.String("phone", value)).String
+	}
+
+	a := hash("key-1", "13800000000")
+	if !strings.HasPrefix(a, "hmac:") || strings.Contains(a, "13800000000") {
+		t.Fatalf("hash = %q", a)
+	}
+	if b := hash("key-1", "13800000000"); b != a {
+		t.Errorf("same key and value: %q != %q", b, a)
+	}
+	if b := hash("key-1", "13900000000"); b == a {
+		t.Errorf("different values share hash %q", a)
+	}
+	if b := hash("key-2", "13800000000"); b == a {
+		t.Errorf("different keys share hash %q", a)
+	}
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"01-zap/rotate"
+	"01-zap/ship"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// SinkOptions 输出目标配置，每个目标有独立的级别范围、编码器和轮转策略
+type SinkOptions struct {
+	Output   string           `yaml:"output"`    // stdout/stderr/文件路径/http(s)收集端地址
+	Encoding string           `yaml:"encoding"`  // console 或 json，为空使用全局配置，收集端默认json
+	Color    bool             `yaml:"color"`     // 彩色输出级别，只对stdout/stderr生效
+	MinLevel string           `yaml:"min_level"` // 最低级别，为空不限制
+	MaxLevel string           `yaml:"max_level"` // 最高级别，为空不限制
+	Rotation *RotationOptions `yaml:"rotation"`  // 文件轮转策略，为空使用全局配置
+	Async    *AsyncOptions    `yaml:"async"`     // 异步写入，为空则同步写入
+	Ship     *ship.Options    `yaml:"ship"`      // 发送到收集端的批量、重试和磁盘队列配置，Output为http(s)地址时生效
+}
+
+// sinks 返回生效的输出目标
+// 配置了Outputs时按Outputs生成，控制台彩色输出，文件使用全局编码且不带颜色
+func (o *LoggerOptions) sinks() []SinkOptions {
+	if len(o.Outputs) == 0 {
+		return o.Sinks
+	}
+
+	sinks := make([]SinkOptions, 0, len(o.Outputs))
+	for _, out := range o.Outputs {
+		sinks = append(sinks, SinkOptions{Output: out, Color: isConsole(out)})
+	}
+	return sinks
+}
+
+func isConsole(output string) bool {
+	return output == "stdout" || output == "stderr"
+}
+
+func isHTTP(output string) bool {
+	return strings.HasPrefix(output, "http://") || strings.HasPrefix(output, "https://")
+}
+
+// dropReporter 异步队列丢弃日志后的回调，参数为输出目标和丢弃的条数
+type dropReporter func(output string, dropped uint64)
+
+// newSinkCores 为每个输出目标创建Core
+func newSinkCores(opts *LoggerOptions, redactor *Redactor, onDrop dropReporter) ([]zapcore.Core, []io.Closer, error) {
+	sinks := opts.sinks()
+	if len(sinks) == 0 {
+		return nil, nil, errors.New("no log outputs configured")
+	}
+
+	cores := make([]zapcore.Core, 0, len(sinks))
+	var closers []io.Closer
+	for _, sink := range sinks {
+		core, closer, err := newSinkCore(opts, sink, onDrop)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, fmt.Errorf("log output %q: %w", sink.Output, err)
+		}
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+		if redactor != nil {
+			core = NewRedactCore(core, redactor)
+		}
+		cores = append(cores, core)
+	}
+	return cores, closers, nil
+}
+
+// newSinkCore 创建单个输出目标的Core
+func newSinkCore(opts *LoggerOptions, sink SinkOptions, onDrop dropReporter) (zapcore.Core, io.Closer, error) {
+	enabler, err := levelRange(sink.MinLevel, sink.MaxLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encoding := sink.Encoding
+	if encoding == "" {
+		encoding = opts.Encoding
+		// 收集端按行解析JSON
+		if isHTTP(sink.Output) {
+			encoding = "json"
+		}
+	}
+	// 文件中不输出颜色转义符
+	encoder, err := newEncoder(encoding, sink.Color && isConsole(sink.Output), opts.TimeLayout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var writer zapcore.WriteSyncer
+	var closer io.Closer
+	switch sink.Output {
+	case "stdout":
+		writer = zapcore.AddSync(os.Stdout)
+	case "stderr":
+		writer = zapcore.AddSync(os.Stderr)
+	default:
+		if isHTTP(sink.Output) {
+			shipOpts := ship.Options{Gzip: true}
+			if sink.Ship != nil {
+				shipOpts = *sink.Ship
+			}
+			shipOpts.Endpoint = sink.Output
+			shipWriter, err := ship.New(shipOpts)
+			if err != nil {
+				return nil, nil, err
+			}
+			writer = shipWriter
+			closer = shipWriter
+			break
+		}
+
+		rotation := opts.Rotation
+		if sink.Rotation != nil {
+			rotation = *sink.Rotation
+		}
+		if rotation.timeBased() {
+			fileWriter, err := newRotateWriter(sink.Output, rotation)
+			if err != nil {
+				return nil, nil, err
+			}
+			// logrotate移走文件后发送SIGHUP，重新打开文件
+			stop := fileWriter.ReopenOnSignal()
+			writer = fileWriter
+			closer = multiCloser{closerFunc(func() error { stop(); return nil }), fileWriter}
+			break
+		}
+		fileWriter := &lumberjack.Logger{
+			Filename:   sink.Output,         // 日志文件路径
+			MaxSize:    rotation.MaxSize,    // 每个日志文件保存的最大尺寸 单位：M
+			MaxBackups: rotation.MaxBackups, // 日志文件最多保存多少个备份
+			MaxAge:     rotation.MaxAge,     // 文件最多保存多少天
+			Compress:   rotation.Compress,   // 是否压缩
+		}
+		writer = zapcore.AddSync(fileWriter)
+		closer = fileWriter
+	}
+
+	if sink.Async != nil {
+		async, err := NewAsyncWriteSyncer(writer, *sink.Async, func(dropped uint64) {
+			if onDrop != nil {
+				onDrop(sink.Output, dropped)
+			}
+		})
+		if err != nil {
+			if closer != nil {
+				closer.Close()
+			}
+			return nil, nil, err
+		}
+		// 先写完队列中的日志再关闭文件
+		closer = multiCloser{async, closer}
+		writer = async
+	}
+
+	return zapcore.NewCore(encoder, writer, enabler), closer, nil
+}
+
+// newRotateWriter 创建按大小和时间轮转的writer
+func newRotateWriter(output string, rotation RotationOptions) (*rotate.Writer, error) {
+	pattern := rotation.Pattern
+	if pattern == "" {
+		pattern = output + ".%Y%m%d"
+	}
+
+	opts := rotate.Options{
+		Pattern:      pattern,
+		MaxSize:      int64(rotation.MaxSize) * 1024 * 1024,
+		RotationTime: rotation.RotationTime,
+		MaxAge:       time.Duration(rotation.MaxAge) * 24 * time.Hour,
+		MaxCount:     rotation.MaxBackups,
+		Compress:     rotation.Compress,
+	}
+	if rotation.Link && pattern != output {
+		opts.LinkName = output
+	}
+	return rotate.New(opts)
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// multiCloser 按顺序关闭，忽略nil
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var errs []error
+	for _, c := range m {
+		if c == nil {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// levelRange 返回[min, max]区间的级别判断，为空表示不限制
+func levelRange(min, max string) (zapcore.LevelEnabler, error) {
+	lo, hi := zapcore.DebugLevel, zapcore.FatalLevel
+	if min != "" {
+		l, err := zapcore.ParseLevel(min)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min level %q: %w", min, err)
+		}
+		lo = l
+	}
+	if max != "" {
+		l, err := zapcore.ParseLevel(max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max level %q: %w", max, err)
+		}
+		hi = l
+	}
+	if lo > hi {
+		return nil, fmt.Errorf("min level %s is greater than max level %s", lo, hi)
+	}
+
+	return zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= lo && l <= hi
+	}), nil
+}
+
+// newEncoder 根据编码方式创建编码器
+func newEncoder(encoding string, color bool, timeLayout string) (zapcore.Encoder, error) {
+	switch encoding {
+	case "console", "":
+		// 设置开发模式
+		developmentCfg := zap.NewDevelopmentEncoderConfig()
+		developmentCfg.EncodeTime = zapcore.TimeEncoderOfLayout(timeLayout)
+		developmentCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		if color {
+			developmentCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(developmentCfg), nil
+	case "json":
+		productionCfg := zap.NewProductionEncoderConfig()
+		productionCfg.TimeKey = "time"
+		productionCfg.EncodeTime = zapcore.TimeEncoderOfLayout(timeLayout)
+		return zapcore.NewJSONEncoder(productionCfg), nil
+	default:
+		return nil, fmt.Errorf("unknown log encoding %q", encoding)
+	}
+}
@@ -0,0 +1,266 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler 通过zapcore.Core输出的slog.Handler，依赖*slog.Logger的库可以写入同一套日志配置
+type SlogHandler struct {
+	core zapcore.Core
+	// groups WithGroup之后还没有属性的分组，有属性时才会真正打开，避免输出空分组
+	groups []string
+}
+
+// NewSlogHandler 创建写入core的slog.Handler
+func NewSlogHandler(core zapcore.Core) *SlogHandler {
+	return &SlogHandler{core: core}
+}
+
+// NewSlogLogger 返回写入全局Logger的*slog.Logger
+func NewSlogLogger() *slog.Logger {
+	return slog.New(NewSlogHandler(FromContext(context.Background()).Core()))
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	ent := zapcore.Entry{
+		Level:   zapLevel(r.Level),
+		Time:    r.Time,
+		Message: r.Message,
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       r.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := make([]zapcore.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, a)
+		return true
+	})
+	ce.Write(h.openGroups(fields)...)
+	return nil
+}
+
+// WithAttrs 属性通过core.With提前编码，之后每条日志不再重复处理
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	return &SlogHandler{core: h.core.With(h.openGroups(fields))}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := make([]string, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+	return &SlogHandler{core: h.core, groups: append(groups, name)}
+}
+
+// openGroups 有字段时在前面加上未打开的分组
+func (h *SlogHandler) openGroups(fields []zapcore.Field) []zapcore.Field {
+	if len(fields) == 0 || len(h.groups) == 0 {
+		return fields
+	}
+	out := make([]zapcore.Field, 0, len(h.groups)+len(fields))
+	for _, g := range h.groups {
+		out = append(out, zap.Namespace(g))
+	}
+	return append(out, fields...)
+}
+
+// appendAttr 把slog属性转换为zap字段，忽略空属性和空分组，key为空的分组直接展开
+func appendAttr(fields []zapcore.Field, a slog.Attr) []zapcore.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			for _, ga := range attrs {
+				fields = appendAttr(fields, ga)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(a.Key, slogGroup(attrs)))
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	default:
+		return append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+}
+
+// slogGroup 把slog分组编码为嵌套对象
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range appendAttr(nil, slog.Attr{Value: slog.GroupValue(g...)}) {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// zapLevel slog级别转换为zap级别，自定义的中间级别向下取整
+func zapLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case l >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case l >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// slogLevel zap级别转换为slog级别，DPanic及以上都作为Error
+func slogLevel(l zapcore.Level) slog.Level {
+	switch {
+	case l >= zapcore.ErrorLevel:
+		return slog.LevelError
+	case l == zapcore.WarnLevel:
+		return slog.LevelWarn
+	case l == zapcore.InfoLevel:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
+// slogCore 输出到slog.Handler的zapcore.Core，用于把zap日志交给基于slog的代码处理
+type slogCore struct {
+	handler slog.Handler
+}
+
+// NewSlogCore 创建写入slog.Handler的Core
+//
+//	logger := zap.New(config.NewSlogCore(slog.NewJSONHandler(os.Stdout, nil)))
+func NewSlogCore(handler slog.Handler) zapcore.Core {
+	return &slogCore{handler: handler}
+}
+
+func (c *slogCore) Enabled(level zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), slogLevel(level))
+}
+
+// With zap.Namespace对应slog的WithGroup
+func (c *slogCore) With(fields []zapcore.Field) zapcore.Core {
+	h := c.handler
+
+	var attrs []slog.Attr
+	for _, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			if len(attrs) > 0 {
+				h = h.WithAttrs(attrs)
+				attrs = nil
+			}
+			h = h.WithGroup(f.Key)
+			continue
+		}
+		attrs = appendField(attrs, f)
+	}
+	if len(attrs) > 0 {
+		h = h.WithAttrs(attrs)
+	}
+	return &slogCore{handler: h}
+}
+
+func (c *slogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *slogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	r := slog.NewRecord(ent.Time, slogLevel(ent.Level), ent.Message, ent.Caller.PC)
+	if ent.LoggerName != "" {
+		r.AddAttrs(slog.String("logger", ent.LoggerName))
+	}
+	r.AddAttrs(fieldAttrs(fields)...)
+	if ent.Stack != "" {
+		r.AddAttrs(slog.String("stacktrace", ent.Stack))
+	}
+	return c.handler.Handle(context.Background(), r)
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+// fieldAttrs 转换字段，zap.Namespace之后的字段放入对应的分组
+func fieldAttrs(fields []zapcore.Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for i, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			rest := fieldAttrs(fields[i+1:])
+			return append(attrs, slog.Attr{Key: f.Key, Value: slog.GroupValue(rest...)})
+		}
+		attrs = appendField(attrs, f)
+	}
+	return attrs
+}
+
+// appendField 借助MapObjectEncoder取出字段的值，zap.Inline会展开为多个属性
+func appendField(attrs []slog.Attr, f zapcore.Field) []slog.Attr {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	if v, ok := enc.Fields[f.Key]; ok {
+		return append(attrs, slog.Any(f.Key, v))
+	}
+
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, enc.Fields[k]))
+	}
+	return attrs
+}
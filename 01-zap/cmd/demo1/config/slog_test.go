@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandlerConformance(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, e := range logs.TakeAll() {
+			m := e.ContextMap()
+			if !e.Time.IsZero() {
+				m[slog.TimeKey] = e.Time
+			}
+			m[slog.LevelKey] = e.Level.String()
+			m[slog.MessageKey] = e.Message
+			ms = append(ms, m)
+		}
+		return ms
+	}
+
+	if err := slogtest.TestHandler(NewSlogHandler(core), results); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSlogHandlerLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := slog.New(NewSlogHandler(core))
+
+	logger.Debug("hidden")
+	logger.Warn("shown", "user", "张三")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if e := entries[0]; e.Level != zapcore.WarnLevel || e.ContextMap()["user"] != "张三" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+}
+
+func TestSlogCore(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zap.New(NewSlogCore(slog.NewJSONHandler(&buf, nil)))
+
+	logger.With(zap.String("module", "user"), zap.Namespace("req")).
+		Info("processing user request", zap.String("requestID", "123456"))
+	logger.Debug("hidden")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal %q: %v", buf.String(), err)
+	}
+	if got["msg"] != "processing user request" || got["module"] != "user" {
+		t.Fatalf("unexpected record %v", got)
+	}
+	req, ok := got["req"].(map[string]any)
+	if !ok || req["requestID"] != "123456" {
+		t.Fatalf("namespace not converted to group: %v", got)
+	}
+}
@@ -2,8 +2,14 @@ package main
 
 import (
 	"01-zap/cmd/demo1/config"
+	"01-zap/crash"
+	"01-zap/errs"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,13 +19,18 @@ import (
 type User struct {
 	Name    string
 	Age     int
-	Address string
+	Address string `log:"redact"` // 整体记录User时脱敏
 }
 
 func main() {
 	// 初始化日志
 	config.InitLogger()
-	defer config.Sync()
+	// 退出前输出最后一次采样汇总，刷新异步队列并关闭文件
+	defer config.Close()
+	// panic时通过config.Logger记录、写转储文件并关闭所有输出后退出
+	// crash退出时不会执行上面的defer，需要通过Flush关闭
+	crash.Setup(crash.Options{Logger: config.Logger, Flush: config.Close, DumpDir: "logs/crash"})
+	defer crash.Recover()
 
 	// 1. 使用Logger（性能更好）
 	config.Logger.Info("server starting...",
@@ -50,73 +61,75 @@ func main() {
 		zap.String("address", user.Address),
 	)
 
+	// 整体记录结构体，带有 log:"redact" 标签的字段会被脱敏
+	config.Logger.Info("user info", zap.Any("user", user))
+
 	// 5. 记录错误和堆栈信息
+	logOperationErrors()
+
+	// 6. 使用with字段创建子logger，通过context传递请求级别的字段
+	ctx := config.WithRequestID(context.Background(), newRequestID())
+	ctx = config.WithContext(ctx, zap.String("module", "user"))
+	handleUserRequest(ctx)
+
+	// 7. 性能测试示例
+	for i := 0; i < 100; i++ {
+		config.Logger.Info("performance test",
+			zap.Int("iteration", i),
+			zap.Time("timestamp", time.Now()),
+		)
+	}
+}
+
+// logOperationErrors errs.Field会展开错误链，输出错误码和最初的堆栈
+func logOperationErrors() {
 	if err := someFunction(); err != nil {
 		config.Logger.Error("operation failed",
-			zap.Error(err),
+			errs.Field(err),
 			zap.String("additional_info", "some context"),
 		)
 	}
 
-	// 使用方式2
+	// 包装后的错误同样可以取到错误码
 	if err := someFunction2(); err != nil {
-		config.Logger.Error("operation failed",
-			zap.Error(err),
-			zap.String("error_code", "INTERNAL_ERROR"),
-		)
+		config.Logger.Error("operation failed", errs.Field(err))
 	}
 
-	// 使用方式3
+	// errors.Join合并的多个错误会逐个输出
 	if err := someFunction3(); err != nil {
-		customErr, ok := err.(*CustomError)
-		if ok {
-			config.Logger.Error("operation failed",
-				zap.Error(err),
-				zap.String("error_code", customErr.Code),
-				zap.String("error_message", customErr.Message),
-			)
-		}
-	}
-
-	// 6. 使用with字段创建子logger
-	userLogger := config.Logger.With(
-		zap.String("module", "user"),
-		zap.String("requestID", "123456"),
-	)
-	userLogger.Info("processing user request")
-
-	// 7. 性能测试示例
-	for i := 0; i < 100; i++ {
-		config.Logger.Info("performance test",
-			zap.Int("iteration", i),
-			zap.Time("timestamp", time.Now()),
-		)
+		config.Logger.Error("operation failed", errs.Field(err))
 	}
 }
 
-// 方式1：使用标准错误
-func someFunction() error {
-	return errors.New("INTERNAL_ERROR")
+// handleUserRequest 从ctx中取出带有请求字段的logger
+func handleUserRequest(ctx context.Context) {
+	config.FromContext(ctx).Info("processing user request")
 }
 
-// 方式2：使用fmt.Errorf
-func someFunction2() error {
-	return fmt.Errorf("INTERNAL_ERROR: %s", "something went wrong")
+// newRequestID 生成随机的请求ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
-// 方式3：自定义错误类型
-type CustomError struct {
-	Code    string
-	Message string
+// 方式1：带错误码的错误
+func someFunction() error {
+	return errs.New("INTERNAL_ERROR", "something went wrong")
 }
 
-func (e *CustomError) Error() string {
-	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+// 方式2：使用fmt.Errorf包装
+func someFunction2() error {
+	if err := someFunction(); err != nil {
+		return fmt.Errorf("load user: %w", err)
+	}
+	return nil
 }
 
+// 方式3：合并多个错误
 func someFunction3() error {
-	return &CustomError{
-		Code:    "INTERNAL_ERROR",
-		Message: "something went wrong",
-	}
+	return errors.Join(
+		errs.Wrap(io.ErrUnexpectedEOF, "READ_FAILED", "read config"),
+		errs.New("INVALID_ARGUMENT", "age cannot be negative"),
+	)
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"01-zap/cmd/demo1/config"
+	"01-zap/logtest"
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogOperationErrors(t *testing.T) {
+	rec := logtest.UseGlobal(t, config.ReplaceLogger)
+
+	logOperationErrors()
+
+	entries := rec.Filter(zapcore.ErrorLevel, "operation failed")
+	if len(entries) != 3 {
+		t.Fatalf("got %d error entries, want 3", len(entries))
+	}
+
+	// 方式1：错误码、额外字段和最初创建错误的堆栈
+	first := rec.AssertLogged(t, zapcore.ErrorLevel, "operation failed",
+		zap.String("additional_info", "some context"),
+	)
+	logtest.AssertStack(t, first, "logOperationErrors")
+	errField, ok := logtest.Field(first, "error").(map[string]any)
+	if !ok {
+		t.Fatalf("error field is %T, want object", logtest.Field(first, "error"))
+	}
+	if errField["code"] != "INTERNAL_ERROR" {
+		t.Errorf("code = %v, want INTERNAL_ERROR", errField["code"])
+	}
+	if stack, _ := errField["stack"].(string); stack == "" {
+		t.Error("missing origin stack")
+	}
+
+	// 方式2：fmt.Errorf包装后仍然能取到错误码
+	wrapped := logtest.Field(entries[1], "error").(map[string]any)
+	if wrapped["code"] != "INTERNAL_ERROR" {
+		t.Errorf("wrapped code = %v, want INTERNAL_ERROR", wrapped["code"])
+	}
+	if causes, _ := wrapped["causes"].([]any); len(causes) != 1 {
+		t.Errorf("wrapped causes = %v, want 1", wrapped["causes"])
+	}
+
+	// 方式3：errors.Join的每个错误都会输出
+	joined := logtest.Field(entries[2], "error").(map[string]any)
+	if joined["code"] != "READ_FAILED" {
+		t.Errorf("joined code = %v, want READ_FAILED", joined["code"])
+	}
+	if causes, _ := joined["causes"].([]any); len(causes) != 3 {
+		t.Errorf("joined causes = %v, want 3", joined["causes"])
+	}
+}
+
+func TestHandleUserRequest(t *testing.T) {
+	rec := logtest.UseGlobal(t, config.ReplaceLogger)
+
+	ctx := config.WithRequestID(context.Background(), "123456")
+	handleUserRequest(ctx)
+
+	rec.AssertLogged(t, zapcore.InfoLevel, "processing user request",
+		zap.String("requestID", "123456"),
+	)
+}
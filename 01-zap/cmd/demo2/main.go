@@ -1,7 +1,12 @@
 package main
 
 import (
+	"01-zap/cmd/demo1/config"
+	"01-zap/crash"
+	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -53,12 +58,13 @@ type User struct {
 }
 
 // 模拟的业务函数
-func processUser(logger *zap.Logger, user User) error {
-	// 使用 With 创建带有上下文的 logger
-	userLogger := logger.With(
+func processUser(ctx context.Context, user User) error {
+	// 使用 WithContext 创建带有上下文的 logger
+	ctx = config.WithContext(ctx,
 		zap.String("user", user.Name),
 		zap.Int("age", user.Age),
 	)
+	userLogger := config.FromContext(ctx)
 
 	userLogger.Info("开始处理用户信息")
 
@@ -89,6 +95,14 @@ func main() {
 	}
 	defer logger.Sync() // 退出前刷新缓冲区
 
+	// 设置为全局logger，ctx中没有logger时使用
+	config.Logger = logger
+	config.SugarLogger = logger.Sugar()
+
+	// 未恢复的panic先记录日志、写转储文件并刷新输出，再以 crash.ExitCodePanic 退出
+	crash.Setup(crash.Options{Logger: logger, Flush: logger.Sync, DumpDir: "logs/crash"})
+	defer crash.Recover()
+
 	// 记录一条简单的日志
 	logger.Info("系统启动")
 
@@ -103,8 +117,9 @@ func main() {
 		{Name: "王五", Age: 30, Address: "广州"},
 	}
 
-	for _, user := range users {
-		err := processUser(logger, user)
+	for i, user := range users {
+		ctx := config.WithRequestID(context.Background(), fmt.Sprintf("req-%d", i+1))
+		err := processUser(ctx, user)
 		if err != nil {
 			logger.Warn("处理用户信息失败",
 				zap.String("user", user.Name),
@@ -113,6 +128,15 @@ func main() {
 		}
 	}
 
+	// 后台goroutine使用SafeGo启动，其中的panic同样会被记录
+	var wg sync.WaitGroup
+	wg.Add(1)
+	crash.SafeGo(func() {
+		defer wg.Done()
+		logger.Info("后台任务执行完成")
+	})
+	wg.Wait()
+
 	// 记录不同级别的日志
 	logger.Debug("这是一条调试日志")
 	logger.Info("这是一条信息日志")
@@ -125,6 +149,6 @@ func main() {
 	// logger.DPanic("这是一条会在开发环境触发 panic 的日志")
 
 	// Fatal 和 Panic 级别的日志慎用
-	// logger.Fatal("这是一条致命错误日志")  // 会导致程序退出
-	// logger.Panic("这是一条会触发 panic 的日志") // 会触发 panic
+	// logger.Fatal("这是一条致命错误日志")  // 会导致程序退出，defer不会执行
+	// logger.Panic("这是一条会触发 panic 的日志") // 会触发 panic，由 crash.Recover 记录后退出
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"01-zap/cmd/demo1/config"
+	"01-zap/logtest"
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestProcessUserInvalidAge(t *testing.T) {
+	rec := logtest.UseGlobal(t, config.ReplaceLogger)
+
+	err := processUser(context.Background(), User{Name: "李四", Age: -5, Address: "上海"})
+	if err == nil || err.Error() != "invalid age" {
+		t.Fatalf("err = %v, want invalid age", err)
+	}
+
+	e := rec.AssertLogged(t, zapcore.ErrorLevel, "用户年龄不合法",
+		zap.String("user", "李四"),
+		zap.Int("age", -5),
+		zap.String("address", "上海"),
+	)
+	if got := logtest.Field(e, "error"); got != "age cannot be negative" {
+		t.Errorf("error field = %v, want age cannot be negative", got)
+	}
+	logtest.AssertStack(t, e, "processUser")
+	rec.AssertNotLogged(t, zapcore.InfoLevel, "用户信息处理完成")
+}
+
+func TestProcessUser(t *testing.T) {
+	rec := logtest.UseGlobal(t, config.ReplaceLogger)
+
+	if err := processUser(context.Background(), User{Name: "张三", Age: 20, Address: "北京"}); err != nil {
+		t.Fatalf("processUser: %v", err)
+	}
+
+	rec.AssertLogged(t, zapcore.InfoLevel, "开始处理用户信息", zap.String("user", "张三"))
+	e := rec.AssertLogged(t, zapcore.InfoLevel, "用户信息处理完成", zap.Int("age", 20))
+	if _, ok := logtest.Field(e, "processTime").(time.Duration); !ok {
+		t.Errorf("processTime = %v, want duration", logtest.Field(e, "processTime"))
+	}
+	if len(rec.Filter(zapcore.ErrorLevel, "用户年龄不合法")) != 0 {
+		t.Error("unexpected error entry")
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 字段表达式支持的运算符，长的写在前面优先匹配
+var operators = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+// exprPattern 判断命令行参数是字段表达式还是文件
+var exprPattern = regexp.MustCompile(`^[A-Za-z_@][\w.@-]*(!=|>=|<=|=|>|<|~)`)
+
+// Expr 字段表达式，如 user=张三、age>18、msg~timeout
+type Expr struct {
+	Key   string
+	Op    string
+	Value string
+
+	num   float64
+	isNum bool
+	re    *regexp.Regexp
+}
+
+// isExpr 判断参数是否是字段表达式
+func isExpr(arg string) bool {
+	return exprPattern.MatchString(arg)
+}
+
+// ParseExpr 解析字段表达式
+func ParseExpr(s string) (*Expr, error) {
+	loc := exprPattern.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return nil, fmt.Errorf("invalid expression %q", s)
+	}
+	e := &Expr{
+		Key:   s[:loc[2]],
+		Op:    s[loc[2]:loc[3]],
+		Value: s[loc[3]:],
+	}
+
+	switch e.Op {
+	case "~":
+		re, err := regexp.Compile(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q: %w", s, err)
+		}
+		e.re = re
+	case ">", "<", ">=", "<=":
+		if f, err := strconv.ParseFloat(e.Value, 64); err == nil {
+			e.num, e.isNum = f, true
+		}
+	}
+	return e, nil
+}
+
+// Match 判断记录是否满足表达式，字段不存在时只有 != 成立
+func (e *Expr) Match(r *Record) bool {
+	v, ok := r.Lookup(e.Key)
+	if !ok {
+		return e.Op == "!="
+	}
+	s := valueString(v)
+
+	switch e.Op {
+	case "=":
+		return s == e.Value
+	case "!=":
+		return s != e.Value
+	case "~":
+		return e.re.MatchString(s)
+	}
+
+	// 两边都是数字时按数字比较，否则按字符串比较
+	var cmp int
+	if f, err := strconv.ParseFloat(s, 64); err == nil && e.isNum {
+		switch {
+		case f < e.num:
+			cmp = -1
+		case f > e.num:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(s, e.Value)
+	}
+
+	switch e.Op {
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp <= 0
+	}
+}
+
+// Filter 命令行指定的所有过滤条件，条件之间是且的关系
+type Filter struct {
+	MinLevel int // -1 不按级别过滤
+	Since    time.Time
+	Until    time.Time
+	Grep     string
+	Exprs    []*Expr
+}
+
+// Match 判断记录是否满足所有条件
+func (f *Filter) Match(r *Record) bool {
+	if f.MinLevel >= 0 {
+		order, ok := levelOrder[r.Level()]
+		if !ok || order < f.MinLevel {
+			return false
+		}
+	}
+
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		t, ok := r.Time()
+		if !ok {
+			return false
+		}
+		if !f.Since.IsZero() && t.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && !t.Before(f.Until) {
+			return false
+		}
+	}
+
+	if f.Grep != "" && !strings.Contains(r.Message(), f.Grep) {
+		return false
+	}
+
+	for _, e := range f.Exprs {
+		if !e.Match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLevel 解析最低级别，为空表示不过滤
+func parseLevel(s string) (int, error) {
+	if s == "" {
+		return -1, nil
+	}
+	order, ok := levelOrder[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("unknown level %q", s)
+	}
+	return order, nil
+}
+
+// parseTimeFlag 解析 -since/-until，支持绝对时间和相对当前时间的时长，如 30m、2h
+func parseTimeFlag(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, ok := parseTime(s); ok {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	zapLine := `{"level":"warn","time":"2025-01-17T10:05:00.000+0800","caller":"demo2/main.go:45","msg":"年龄过小","user":"李四","age":15}`
+	logrusLine := `{"data":{"user":"张三","age":19,"req":{"id":"123"}},"level":"warning","msg":"更新用户","time":"2025-01-17 10:00:00"}`
+
+	tests := []struct {
+		name  string
+		line  string
+		exprs []string
+		level string
+		want  bool
+	}{
+		{"equal", zapLine, []string{"user=李四"}, "", true},
+		{"not equal", zapLine, []string{"user!=李四"}, "", false},
+		{"missing field", zapLine, []string{"email!=a"}, "", true},
+		{"number", zapLine, []string{"age>18"}, "", false},
+		{"number range", zapLine, []string{"age>=15", "age<16"}, "", true},
+		{"regexp", zapLine, []string{"msg~过小$"}, "", true},
+		{"logrus data", logrusLine, []string{"user=张三", "age>18"}, "", true},
+		{"nested", logrusLine, []string{"req.id=123"}, "", true},
+		{"level", logrusLine, nil, "warn", true},
+		{"level too low", zapLine, nil, "error", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := parseRecord([]byte(tt.line))
+			if !ok {
+				t.Fatalf("parseRecord(%s) failed", tt.line)
+			}
+			level, err := parseLevel(tt.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := &Filter{MinLevel: level}
+			for _, s := range tt.exprs {
+				if !isExpr(s) {
+					t.Fatalf("isExpr(%q) = false", s)
+				}
+				e, err := ParseExpr(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.Exprs = append(f.Exprs, e)
+			}
+			if got := f.Match(r); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterTime(t *testing.T) {
+	r, _ := parseRecord([]byte(`{"level":"info","ts":1737079200.5,"msg":"ok"}`))
+	since := time.Unix(1737079200, 0)
+
+	if f := (&Filter{MinLevel: -1, Since: since}); !f.Match(r) {
+		t.Error("record after since should match")
+	}
+	if f := (&Filter{MinLevel: -1, Until: since}); f.Match(r) {
+		t.Error("record after until should not match")
+	}
+	if isExpr("logs/app.log") {
+		t.Error("file path should not be an expression")
+	}
+}
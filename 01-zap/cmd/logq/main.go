@@ -0,0 +1,132 @@
+// logq 查询和跟踪JSON格式的日志文件
+//
+// 支持demo2输出的 time/level/caller/msg 格式，以及03-logrus把字段放在data下的格式，
+// 可以直接读取轮转后的文件和gzip压缩文件。
+//
+//	logq -level warn -since 1h logs/app.log*
+//	logq -f -grep 用户 user=张三 age>18 logs/app.log
+//	logq -json 'msg~timeout' logs/app.log.20250117.gz
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "logq:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("logq", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: logq [选项] [字段表达式...] 文件...")
+		fmt.Fprintln(fs.Output(), "字段表达式: key=value key!=value key>n key>=n key<n key<=n key~正则，嵌套字段使用 a.b")
+		fs.PrintDefaults()
+	}
+	var (
+		followFlag = fs.Bool("f", false, "读完后继续跟踪文件新增的内容")
+		interval   = fs.Duration("interval", 500*time.Millisecond, "跟踪时检查文件的间隔")
+		level      = fs.String("level", "", "最低级别，如 info、warn、error")
+		since      = fs.String("since", "", "开始时间，如 2025-01-17 10:00:00 或 1h（一小时前）")
+		until      = fs.String("until", "", "结束时间（不包含），格式同 -since")
+		grep       = fs.String("grep", "", "消息包含的字符串")
+		jsonOutput = fs.Bool("json", false, "原样输出JSON")
+		color      = fs.String("color", "auto", "是否使用颜色: auto、always、never")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	filter := &Filter{Grep: *grep}
+	var err error
+	if filter.MinLevel, err = parseLevel(*level); err != nil {
+		return err
+	}
+	if filter.Since, err = parseTimeFlag(*since, now); err != nil {
+		return err
+	}
+	if filter.Until, err = parseTimeFlag(*until, now); err != nil {
+		return err
+	}
+
+	var patterns []string
+	for _, arg := range fs.Args() {
+		if !isExpr(arg) {
+			patterns = append(patterns, arg)
+			continue
+		}
+		e, err := ParseExpr(arg)
+		if err != nil {
+			return err
+		}
+		filter.Exprs = append(filter.Exprs, e)
+	}
+	if len(patterns) == 0 {
+		fs.Usage()
+		return fmt.Errorf("no log files")
+	}
+
+	files, err := expandFiles(patterns)
+	if err != nil {
+		return err
+	}
+
+	useColor, err := colorEnabled(*color)
+	if err != nil {
+		return err
+	}
+	p := newPrinter(os.Stdout, *jsonOutput, useColor)
+	defer p.Flush()
+
+	handle := func(line []byte) {
+		// 控制台格式等非JSON行直接跳过
+		r, ok := parseRecord(line)
+		if ok && filter.Match(r) {
+			p.Print(r)
+		}
+	}
+
+	tailers, err := readFiles(files, *followFlag, handle)
+	defer func() {
+		for _, t := range tailers {
+			t.Close()
+		}
+	}()
+	if err != nil {
+		return err
+	}
+	if err := p.Flush(); err != nil || !*followFlag {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return follow(ctx, tailers, *interval, handle, func() { p.Flush() })
+}
+
+// colorEnabled auto模式下只在输出到终端时使用颜色
+func colorEnabled(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, fmt.Errorf("invalid color mode %q", mode)
+	}
+}
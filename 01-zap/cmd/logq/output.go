@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// 颜色与zap的CapitalColorLevelEncoder保持一致
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+)
+
+// 美化输出时单独显示的key，不再出现在字段列表中
+var reservedKeys = map[string]struct{}{
+	"time": {}, "ts": {}, "timestamp": {}, "@timestamp": {},
+	"level": {}, "severity": {},
+	"msg": {}, "message": {},
+	"caller": {}, "file": {}, "func": {},
+	"stacktrace": {}, "stack": {},
+}
+
+// printer 输出匹配的日志
+type printer struct {
+	w     *bufio.Writer
+	json  bool
+	color bool
+}
+
+func newPrinter(w io.Writer, jsonOutput, color bool) *printer {
+	return &printer{w: bufio.NewWriter(w), json: jsonOutput, color: color}
+}
+
+// Print 输出一条记录，JSON模式下原样输出
+func (p *printer) Print(r *Record) {
+	if p.json {
+		p.w.Write(r.Raw)
+		p.w.WriteByte('\n')
+		return
+	}
+
+	ts := r.first(timeKeys)
+	if t, ok := r.Time(); ok {
+		ts = t.Format("2006-01-02 15:04:05.000")
+	}
+	if ts != "" {
+		p.paint(colorGray, ts)
+		p.w.WriteByte(' ')
+	}
+
+	level := r.Level()
+	p.paint(levelColor(level), fmt.Sprintf("%-5s", strings.ToUpper(level)))
+
+	if caller := r.Caller(); caller != "" {
+		p.w.WriteByte(' ')
+		p.paint(colorGray, caller)
+	}
+	p.w.WriteByte(' ')
+	p.w.WriteString(r.Message())
+
+	for _, kv := range fields(r) {
+		p.w.WriteByte(' ')
+		p.paint(colorCyan, kv[0]+"=")
+		p.w.WriteString(kv[1])
+	}
+	p.w.WriteByte('\n')
+
+	for _, k := range []string{"stacktrace", "stack"} {
+		if v, ok := r.Fields[k]; ok {
+			for _, line := range strings.Split(valueString(v), "\n") {
+				p.w.WriteString("    ")
+				p.w.WriteString(line)
+				p.w.WriteByte('\n')
+			}
+		}
+	}
+}
+
+// Flush 把缓冲区写到输出
+func (p *printer) Flush() error {
+	return p.w.Flush()
+}
+
+func (p *printer) paint(color, s string) {
+	if !p.color || color == "" {
+		p.w.WriteString(s)
+		return
+	}
+	p.w.WriteString(color)
+	p.w.WriteString(s)
+	p.w.WriteString(colorReset)
+}
+
+func levelColor(level string) string {
+	switch level {
+	case "trace", "debug":
+		return colorMagenta
+	case "info":
+		return colorBlue
+	case "warn", "warning":
+		return colorYellow
+	case "":
+		return ""
+	default:
+		return colorRed
+	}
+}
+
+// fields 按key排序返回要输出的字段，logrus的data字段展开到顶层
+func fields(r *Record) [][2]string {
+	var kvs [][2]string
+	add := func(m map[string]any) {
+		for k, v := range m {
+			if _, ok := reservedKeys[k]; ok {
+				continue
+			}
+			if k == dataKey {
+				if data, ok := v.(map[string]any); ok {
+					for dk, dv := range data {
+						kvs = append(kvs, [2]string{dk, quote(dv)})
+					}
+					continue
+				}
+			}
+			kvs = append(kvs, [2]string{k, quote(v)})
+		}
+	}
+	add(r.Fields)
+
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i][0] < kvs[j][0] })
+	return kvs
+}
+
+// quote 包含空格的字符串加引号，方便区分字段边界
+func quote(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		return valueString(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+	return s
+}
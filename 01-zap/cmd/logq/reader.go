@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const maxLineSize = 1024 * 1024
+
+// expandFiles 展开通配符，去掉指向同一文件的路径（如 rotate 的软链），按修改时间从旧到新排序
+func expandFiles(args []string) ([]string, error) {
+	type entry struct {
+		path    string
+		modTime time.Time
+	}
+	seen := make(map[string]int)
+	var entries []entry
+
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no such file: %s", arg)
+		}
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() {
+				continue
+			}
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				return nil, err
+			}
+			if i, ok := seen[real]; ok {
+				// 跟踪时软链会随轮转切换到新文件，优先使用软链
+				if real != path {
+					entries[i].path = path
+				}
+				continue
+			}
+			seen[real] = len(entries)
+			entries = append(entries, entry{path: path, modTime: info.ModTime()})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	files := make([]string, len(entries))
+	for i, e := range entries {
+		files[i] = e.path
+	}
+	return files, nil
+}
+
+// readGzip 读取轮转后压缩的文件
+func readGzip(path string, fn func(line []byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	defer gz.Close()
+
+	sc := bufio.NewScanner(gz)
+	sc.Buffer(make([]byte, 64*1024), maxLineSize)
+	for sc.Scan() {
+		fn(sc.Bytes())
+	}
+	return sc.Err()
+}
+
+// tailer 读取普通文件并记录读到的位置，跟踪模式下继续读取新增的内容
+type tailer struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte // 还没有换行符的半行
+}
+
+func newTailer(path string) (*tailer, error) {
+	t := &tailer{path: path}
+	if err := t.open(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *tailer) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	t.file, t.info, t.offset, t.partial = f, info, 0, nil
+	return nil
+}
+
+// read 读取offset之后的完整行
+func (t *tailer) read(fn func(line []byte)) error {
+	if _, err := t.file.Seek(t.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(t.file)
+	for {
+		chunk, err := br.ReadSlice('\n')
+		t.offset += int64(len(chunk))
+		if err == bufio.ErrBufferFull {
+			t.partial = append(t.partial, chunk...)
+			continue
+		}
+		if err != nil {
+			t.partial = append(t.partial, chunk...)
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		line := chunk
+		if len(t.partial) > 0 {
+			line = append(t.partial, chunk...)
+			t.partial = t.partial[:0]
+		}
+		fn(bytes.TrimRight(line, "\r\n"))
+	}
+}
+
+// poll 检查文件是否被轮转或截断，然后读取新增的内容
+func (t *tailer) poll(fn func(line []byte)) error {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		// 轮转过程中文件可能短暂不存在
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	switch {
+	case !os.SameFile(info, t.info):
+		// 轮转后路径指向了新文件，先读完旧文件剩余的内容
+		if err := t.read(fn); err != nil {
+			return err
+		}
+		t.flush(fn)
+		t.file.Close()
+		if err := t.open(); err != nil {
+			return err
+		}
+	case info.Size() < t.offset:
+		// 文件被截断，从头开始读
+		t.offset, t.partial = 0, nil
+	case info.Size() == t.offset:
+		return nil
+	}
+	return t.read(fn)
+}
+
+// flush 输出最后没有换行符的一行
+func (t *tailer) flush(fn func(line []byte)) {
+	if len(t.partial) > 0 {
+		fn(t.partial)
+		t.partial = nil
+	}
+}
+
+func (t *tailer) Close() error {
+	return t.file.Close()
+}
+
+// readFiles 按顺序读取所有文件，follow为true时返回未压缩文件的tailer用于继续跟踪
+func readFiles(files []string, follow bool, fn func(line []byte)) ([]*tailer, error) {
+	var tailers []*tailer
+	for _, path := range files {
+		if strings.HasSuffix(path, ".gz") {
+			if err := readGzip(path, fn); err != nil {
+				return tailers, err
+			}
+			continue
+		}
+
+		t, err := newTailer(path)
+		if err != nil {
+			return tailers, err
+		}
+		if err := t.read(fn); err != nil {
+			t.Close()
+			return tailers, err
+		}
+		if !follow {
+			t.flush(fn)
+			t.Close()
+			continue
+		}
+		tailers = append(tailers, t)
+	}
+	return tailers, nil
+}
+
+// follow 定期检查文件的新内容，直到ctx结束
+func follow(ctx context.Context, tailers []*tailer, interval time.Duration, fn func(line []byte), after func()) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		for _, t := range tailers {
+			if err := t.poll(fn); err != nil {
+				return fmt.Errorf("%s: %w", t.path, err)
+			}
+		}
+		after()
+	}
+}
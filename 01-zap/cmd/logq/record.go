@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 兼容zap和logrus的常用key
+var (
+	timeKeys  = []string{"time", "ts", "timestamp", "@timestamp"}
+	levelKeys = []string{"level", "severity"}
+	msgKeys   = []string{"msg", "message"}
+	callerKey = []string{"caller", "file"}
+	// logrus的JSONFormatter通过DataKey把字段放在data下
+	dataKey = "data"
+)
+
+// 日志中常见的时间格式
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000Z0700", // zap ISO8601TimeEncoder
+	"2006-01-02 15:04:05.000",      // demo1 控制台时间格式
+	"2006-01-02 15:04:05",          // 03-logrus TimestampFormat
+	"2006-01-02T15:04:05",
+}
+
+// levelOrder 级别从低到高，兼容zap和logrus
+var levelOrder = map[string]int{
+	"trace":   0,
+	"debug":   1,
+	"info":    2,
+	"warn":    3,
+	"warning": 3,
+	"error":   4,
+	"dpanic":  5,
+	"panic":   6,
+	"fatal":   7,
+}
+
+// Record 一行JSON日志
+type Record struct {
+	Raw    []byte
+	Fields map[string]any
+}
+
+// parseRecord 解析一行JSON日志，不是JSON对象时返回false
+func parseRecord(line []byte) (*Record, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, false
+	}
+	return &Record{Raw: line, Fields: fields}, true
+}
+
+// Lookup 查找字段，支持 a.b 形式的嵌套字段，顶层没有时再查找logrus的data字段
+func (r *Record) Lookup(key string) (any, bool) {
+	if v, ok := lookupPath(r.Fields, key); ok {
+		return v, true
+	}
+	if data, ok := r.Fields[dataKey].(map[string]any); ok {
+		return lookupPath(data, key)
+	}
+	return nil, false
+}
+
+func lookupPath(m map[string]any, key string) (any, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	head, rest, found := strings.Cut(key, ".")
+	if !found {
+		return nil, false
+	}
+	child, ok := m[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookupPath(child, rest)
+}
+
+func (r *Record) first(keys []string) string {
+	for _, k := range keys {
+		if v, ok := r.Fields[k]; ok {
+			return valueString(v)
+		}
+	}
+	return ""
+}
+
+// Time 解析日志时间，支持常见的字符串格式和zap的秒级时间戳
+func (r *Record) Time() (time.Time, bool) {
+	for _, k := range timeKeys {
+		v, ok := r.Fields[k]
+		if !ok {
+			continue
+		}
+		switch v := v.(type) {
+		case json.Number:
+			f, err := v.Float64()
+			if err != nil {
+				return time.Time{}, false
+			}
+			sec := int64(f)
+			return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+		case string:
+			return parseTime(v)
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseTime 按timeLayouts解析时间，没有时区的格式按本地时间处理
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Level 返回小写的级别
+func (r *Record) Level() string {
+	return strings.ToLower(r.first(levelKeys))
+}
+
+// Message 返回日志消息
+func (r *Record) Message() string {
+	return r.first(msgKeys)
+}
+
+// Caller 返回调用者
+func (r *Record) Caller() string {
+	return r.first(callerKey)
+}
+
+// valueString 把JSON值转换为用于比较和输出的字符串
+func valueString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
@@ -0,0 +1,167 @@
+// Package crash 捕获未恢复的panic，记录日志并刷新所有输出后退出
+//
+// 其他goroutine中未恢复的panic会直接终止进程，main中的 defer logger.Sync() 不会执行，
+// 异步队列和批量发送中的日志会丢失。使用方式：
+//
+//	func main() {
+//		config.InitLogger()
+//		crash.Setup(crash.Options{Logger: config.Logger, Flush: config.Close, DumpDir: "logs/crash"})
+//		defer crash.Recover()
+//
+//		crash.SafeGo(func() { ... })
+//	}
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ExitCodePanic 默认退出码，与Go运行时因panic退出时相同
+const ExitCodePanic = 2
+
+// Options 崩溃处理配置
+type Options struct {
+	Logger   *zap.Logger    // 记录panic的logger，为空时使用zap.L()
+	Flush    func() error   // 退出前刷新并关闭所有输出，为空时只调用Logger.Sync
+	DumpDir  string         // 崩溃转储文件目录，为空不写转储文件
+	ExitCode int            // 退出码，默认 ExitCodePanic
+	Exit     func(code int) // 退出函数，默认 os.Exit，测试中可以替换
+}
+
+var (
+	mu      sync.Mutex
+	opts    Options
+	crashed bool // 已经有goroutine在处理崩溃
+)
+
+// Setup 设置崩溃处理配置
+func Setup(o Options) {
+	mu.Lock()
+	defer mu.Unlock()
+	opts = o
+}
+
+// Recover 在main和goroutine的最外层 defer crash.Recover()，panic时记录日志后退出
+func Recover() {
+	if v := recover(); v != nil {
+		handle(v, debug.Stack())
+	}
+}
+
+// SafeGo 启动goroutine，panic时记录日志并刷新输出后退出，而不是直接终止进程
+func SafeGo(fn func()) {
+	go func() {
+		defer Recover()
+		fn()
+	}()
+}
+
+// handle 记录panic、写转储文件、刷新输出，然后退出
+// 多个goroutine同时panic时只处理第一个，其余的等待进程退出
+func handle(v any, stack []byte) {
+	mu.Lock()
+	if crashed {
+		mu.Unlock()
+		select {}
+	}
+	crashed = true
+	o := opts
+	mu.Unlock()
+
+	logger := o.Logger
+	if logger == nil {
+		logger = zap.L()
+	}
+	// 堆栈已经通过stacktrace字段输出，这里不再重复添加；Fatal的默认行为会直接退出，改为由这里退出
+	logger = logger.WithOptions(
+		zap.WithCaller(false),
+		zap.AddStacktrace(zapcore.InvalidLevel),
+		zap.WithFatalHook(noExit{}),
+	)
+
+	fields := []zap.Field{
+		zap.String("panic", fmt.Sprint(v)),
+		zap.String("stacktrace", string(stack)),
+	}
+	if err, ok := v.(error); ok {
+		fields = append(fields, zap.Error(err))
+	}
+	if o.DumpDir != "" {
+		path, err := writeDump(o.DumpDir, v, stack)
+		if err != nil {
+			fields = append(fields, zap.NamedError("dumpError", err))
+		} else {
+			fields = append(fields, zap.String("dump", path))
+		}
+	}
+	logger.Fatal("panic recovered", fields...)
+
+	_ = logger.Sync()
+	if o.Flush != nil {
+		if err := o.Flush(); err != nil {
+			fmt.Fprintln(os.Stderr, "crash: flush logs:", err)
+		}
+	}
+
+	code := o.ExitCode
+	if code == 0 {
+		code = ExitCodePanic
+	}
+	exit := o.Exit
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(code)
+}
+
+// noExit Fatal日志写入后不退出
+type noExit struct{}
+
+func (noExit) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
+
+// writeDump 写入崩溃转储文件，包含panic值、构建信息和所有goroutine的堆栈
+func writeDump(dir string, v any, stack []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.txt", now.Format("20060102-150405"), os.Getpid()))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", now.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "pid: %d\n", os.Getpid())
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "panic: %v\n\n", v)
+	b.Write(stack)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(&b, "\n== build info ==\n%s", info)
+	}
+	fmt.Fprintf(&b, "\n== goroutines ==\n%s", allStacks())
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// allStacks 返回所有goroutine的堆栈，缓冲区不够时加倍
+func allStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64<<20 {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
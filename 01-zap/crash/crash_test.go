@@ -0,0 +1,112 @@
+package crash
+
+import (
+	"01-zap/logtest"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// setupTest 替换退出函数，返回收到的退出码
+func setupTest(t *testing.T, o Options) (<-chan int, *logtest.Recorder) {
+	t.Helper()
+	logger, rec := logtest.New(zapcore.DebugLevel)
+
+	codes := make(chan int, 1)
+	flushed := false
+	o.Logger = logger
+	o.Flush = func() error { flushed = true; return nil }
+	o.Exit = func(code int) {
+		if !flushed {
+			t.Error("exit before flush")
+		}
+		codes <- code
+	}
+	Setup(o)
+	t.Cleanup(func() {
+		mu.Lock()
+		opts, crashed = Options{}, false
+		mu.Unlock()
+	})
+	return codes, rec
+}
+
+func TestSafeGo(t *testing.T) {
+	dir := t.TempDir()
+	codes, rec := setupTest(t, Options{DumpDir: dir})
+
+	SafeGo(func() {
+		var m map[string]int
+		m["boom"] = 1
+	})
+
+	if code := <-codes; code != ExitCodePanic {
+		t.Errorf("exit code = %d, want %d", code, ExitCodePanic)
+	}
+
+	e := rec.AssertLogged(t, zapcore.FatalLevel, "panic recovered")
+	if panicValue, _ := logtest.Field(e, "panic").(string); !strings.Contains(panicValue, "nil map") {
+		t.Errorf("panic = %v", panicValue)
+	}
+	if stack, _ := logtest.Field(e, "stacktrace").(string); !strings.Contains(stack, "TestSafeGo") {
+		t.Errorf("stacktrace does not contain the panicking function:\n%s", stack)
+	}
+
+	path, ok := logtest.Field(e, "dump").(string)
+	if !ok {
+		t.Fatal("missing dump field")
+	}
+	dump, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"panic: assignment to entry in nil map", "== build info ==", "== goroutines =="} {
+		if !strings.Contains(string(dump), want) {
+			t.Errorf("dump does not contain %q", want)
+		}
+	}
+}
+
+func TestRecoverExitCode(t *testing.T) {
+	codes, rec := setupTest(t, Options{ExitCode: 70})
+
+	func() {
+		defer Recover()
+		panic(errors.New("boom"))
+	}()
+
+	if code := <-codes; code != 70 {
+		t.Errorf("exit code = %d, want 70", code)
+	}
+	rec.AssertLogged(t, zapcore.FatalLevel, "panic recovered",
+		zap.String("panic", "boom"),
+		zap.String("error", "boom"),
+	)
+}
+
+func TestDefaultLogger(t *testing.T) {
+	logger, rec := logtest.New(zapcore.DebugLevel)
+	t.Cleanup(zap.ReplaceGlobals(logger))
+
+	codes := make(chan int, 1)
+	Setup(Options{Exit: func(code int) { codes <- code }})
+	t.Cleanup(func() {
+		mu.Lock()
+		opts, crashed = Options{}, false
+		mu.Unlock()
+	})
+
+	func() {
+		defer Recover()
+		panic("boom")
+	}()
+
+	if code := <-codes; code != ExitCodePanic {
+		t.Errorf("exit code = %d, want %d", code, ExitCodePanic)
+	}
+	rec.AssertLogged(t, zapcore.FatalLevel, "panic recovered", zap.String("panic", "boom"))
+}
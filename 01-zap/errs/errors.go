@@ -0,0 +1,102 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Error 带错误码的错误，记录日志时输出 code、message 和创建时的堆栈
+type Error struct {
+	Code    string
+	Message string
+
+	cause error
+	stack []uintptr
+}
+
+// New 创建带错误码的错误
+func New(code, message string) *Error {
+	return &Error{Code: code, Message: message, stack: callers()}
+}
+
+// Newf 使用格式化的message创建错误
+func Newf(code, format string, args ...any) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap 为底层错误添加错误码，err为nil时返回nil
+// 底层错误已经带有堆栈时不再重复记录
+func Wrap(err error, code, message string) error {
+	if err == nil {
+		return nil
+	}
+	e := &Error{Code: code, Message: message, cause: err}
+	if !hasStack(err) {
+		e.stack = callers()
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Unwrap 支持errors.Is和errors.As
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// StackTrace 返回错误创建时的堆栈
+func (e *Error) StackTrace() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler，只输出本层的错误码和信息
+func (e *Error) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("code", e.Code)
+	enc.AddString("message", e.Message)
+	return nil
+}
+
+// Code 返回错误链中第一个错误码，没有时返回空字符串
+func Code(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// hasStack 判断错误链中是否已经记录了堆栈
+func hasStack(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && len(e.stack) > 0
+}
+
+// callers 记录调用New/Wrap的位置开始的堆栈
+func callers() []uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
@@ -0,0 +1,101 @@
+package errs
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Field 把错误链展开为结构化字段，key为error
+//
+//	{"error": {"message": "...", "code": "INTERNAL_ERROR", "causes": [...], "stack": "..."}}
+func Field(err error) zap.Field {
+	return NamedField("error", err)
+}
+
+// NamedField 与Field相同，可以指定key
+func NamedField(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, chain{err})
+}
+
+// chain 沿errors.Unwrap和errors.Join展开的错误链
+type chain struct {
+	err error
+}
+
+func (c chain) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", c.err.Error())
+	if code := Code(c.err); code != "" {
+		enc.AddString("code", code)
+	}
+
+	// 堆栈只输出一次，使用最早创建的错误的堆栈
+	var origin *Error
+	causes := make(causeArray, 0, 4)
+	walk(c.err, func(err error) {
+		// 最外层的信息已经在message中，不再重复
+		if err != c.err {
+			causes = append(causes, err)
+		}
+		if e, ok := err.(*Error); ok && len(e.stack) > 0 {
+			origin = e
+		}
+	})
+
+	if len(causes) > 0 {
+		if err := enc.AddArray("causes", causes); err != nil {
+			return err
+		}
+	}
+	if origin != nil {
+		enc.AddString("stack", origin.StackTrace())
+	}
+	return nil
+}
+
+// walk 深度优先遍历错误链
+func walk(err error, visit func(error)) {
+	if err == nil {
+		return
+	}
+	visit(err)
+
+	switch x := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, e := range x.Unwrap() {
+			walk(e, visit)
+		}
+	case interface{ Unwrap() error }:
+		walk(x.Unwrap(), visit)
+	}
+}
+
+// causeArray 错误链中的每一层
+type causeArray []error
+
+func (a causeArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range a {
+		if err := enc.AppendObject(cause{err}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cause 错误链中的一层，带错误码的错误只输出本层信息
+type cause struct {
+	err error
+}
+
+func (c cause) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if e, ok := c.err.(*Error); ok {
+		return e.MarshalLogObject(enc)
+	}
+	enc.AddString("message", c.err.Error())
+	enc.AddString("type", fmt.Sprintf("%T", c.err))
+	return nil
+}
@@ -0,0 +1,125 @@
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Recorder 记录测试中输出的日志，用于断言级别、消息、字段和堆栈
+type Recorder struct {
+	logs *observer.ObservedLogs
+}
+
+// New 创建写入内存的logger，与通常的配置一样带调用者信息，Error及以上带堆栈
+func New(level zapcore.LevelEnabler) (*zap.Logger, *Recorder) {
+	core, logs := observer.New(level)
+	logger := zap.New(core,
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+	)
+	return logger, &Recorder{logs: logs}
+}
+
+// UseGlobal 创建Debug级别的logger并通过replace替换全局logger，测试结束后调用replace返回的函数恢复
+// 如 logtest.UseGlobal(t, config.ReplaceLogger)
+func UseGlobal(t testing.TB, replace func(*zap.Logger) func()) *Recorder {
+	t.Helper()
+
+	logger, rec := New(zapcore.DebugLevel)
+	t.Cleanup(replace(logger))
+	return rec
+}
+
+// Entries 返回所有日志
+func (r *Recorder) Entries() []observer.LoggedEntry {
+	return r.logs.All()
+}
+
+// Reset 清空已记录的日志
+func (r *Recorder) Reset() {
+	r.logs.TakeAll()
+}
+
+// Filter 返回指定级别和消息的日志
+func (r *Recorder) Filter(level zapcore.Level, msg string) []observer.LoggedEntry {
+	var entries []observer.LoggedEntry
+	for _, e := range r.logs.FilterMessage(msg).All() {
+		if e.Level == level {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// AssertLogged 断言存在指定级别和消息、并且包含全部fields的日志，返回第一条匹配的日志
+func (r *Recorder) AssertLogged(t testing.TB, level zapcore.Level, msg string, fields ...zap.Field) observer.LoggedEntry {
+	t.Helper()
+
+	want := fieldMap(fields)
+	for _, e := range r.Filter(level, msg) {
+		if containsFields(e.ContextMap(), want) {
+			return e
+		}
+	}
+	t.Fatalf("no %s entry %q with fields %v, got:\n%s", level, msg, want, r.dump())
+	return observer.LoggedEntry{}
+}
+
+// AssertNotLogged 断言不存在指定级别和消息的日志
+func (r *Recorder) AssertNotLogged(t testing.TB, level zapcore.Level, msg string) {
+	t.Helper()
+
+	if entries := r.Filter(level, msg); len(entries) > 0 {
+		t.Fatalf("unexpected %s entry %q, got:\n%s", level, msg, r.dump())
+	}
+}
+
+// AssertStack 断言日志带有堆栈，并且堆栈中包含指定的函数
+func AssertStack(t testing.TB, e observer.LoggedEntry, function string) {
+	t.Helper()
+
+	if e.Stack == "" {
+		t.Fatalf("entry %q has no stacktrace", e.Message)
+	}
+	if !strings.Contains(e.Stack, function) {
+		t.Fatalf("stacktrace of %q does not contain %s:\n%s", e.Message, function, e.Stack)
+	}
+}
+
+// Field 返回日志中字段的值，对象类型的字段返回map[string]any
+func Field(e observer.LoggedEntry, key string) any {
+	return e.ContextMap()[key]
+}
+
+// fieldMap 借助MapObjectEncoder把字段转换为与ContextMap一致的值
+func fieldMap(fields []zap.Field) map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+func containsFields(got, want map[string]any) bool {
+	for k, v := range want {
+		if !reflect.DeepEqual(got[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// dump 输出所有日志，断言失败时便于定位
+func (r *Recorder) dump() string {
+	var b strings.Builder
+	for _, e := range r.logs.All() {
+		fmt.Fprintf(&b, "\t%s\t%s\t%v\n", e.Level, e.Message, e.ContextMap())
+	}
+	return b.String()
+}
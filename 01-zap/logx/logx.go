@@ -0,0 +1,120 @@
+// Package logx 与具体日志库无关的Logger接口
+//
+// 共享的handlers、services等包只依赖logx.Logger，启动时再选择zap、logrus或slog作为实现：
+//
+//	logger := logx.NewZap(config.Logger)
+//	svc := services.NewUserService(logger)
+package logx
+
+import (
+	"context"
+	"time"
+)
+
+// Logger 分级别、可以追加字段、从ctx中读取请求字段的日志接口
+type Logger interface {
+	Debug(ctx context.Context, msg string, fields ...Field)
+	Info(ctx context.Context, msg string, fields ...Field)
+	Warn(ctx context.Context, msg string, fields ...Field)
+	Error(ctx context.Context, msg string, fields ...Field)
+	// With 返回带有固定字段的子logger
+	With(fields ...Field) Logger
+}
+
+// Field 日志字段，值由各个实现自行编码
+type Field struct {
+	Key   string
+	Value any
+}
+
+// ErrorKey Err使用的key，与zap.Error和logrus.WithError一致
+const ErrorKey = "error"
+
+// String 字符串字段
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int 整数字段
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool 布尔字段
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration 时长字段
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Any 任意类型的字段
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err 错误字段，err为nil时返回的字段会被忽略
+func Err(err error) Field {
+	if err == nil {
+		return Field{}
+	}
+	return Field{Key: ErrorKey, Value: err}
+}
+
+type fieldsKey struct{}
+
+// WithContext 在ctx中追加请求级别的字段，所有实现输出日志时都会带上
+//
+//	ctx = logx.WithContext(ctx, logx.String("requestID", id))
+//	logger.Info(ctx, "processing user request")
+func WithContext(ctx context.Context, fields ...Field) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	old := ContextFields(ctx)
+	merged := make([]Field, 0, len(old)+len(fields))
+	merged = append(merged, old...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// ContextFields 返回ctx中携带的字段
+func ContextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	return fields
+}
+
+// Merge 把ctx中的字段放在fields之前，跳过Err(nil)产生的空字段，供各个实现使用
+func Merge(ctx context.Context, fields []Field) []Field {
+	ctxFields := ContextFields(ctx)
+	out := make([]Field, 0, len(ctxFields)+len(fields))
+	for _, f := range ctxFields {
+		if f.Key != "" {
+			out = append(out, f)
+		}
+	}
+	for _, f := range fields {
+		if f.Key != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Nop 不输出任何日志的Logger，用于测试和未配置日志的场景
+func Nop() Logger {
+	return nop{}
+}
+
+type nop struct{}
+
+func (nop) Debug(context.Context, string, ...Field) {}
+func (nop) Info(context.Context, string, ...Field)  {}
+func (nop) Warn(context.Context, string, ...Field)  {}
+func (nop) Error(context.Context, string, ...Field) {}
+func (n nop) With(...Field) Logger                  { return n }
@@ -0,0 +1,98 @@
+package logx
+
+import (
+	"01-zap/logtest"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestZap(t *testing.T) {
+	zl, rec := logtest.New(zapcore.InfoLevel)
+	logger := NewZap(zl).With(String("module", "user"))
+
+	ctx := WithContext(context.Background(), String("requestID", "req-1"))
+	logger.Debug(ctx, "ignored")
+	logger.Error(ctx, "create user failed", Int("id", 7), Err(errors.New("duplicate")), Err(nil))
+
+	rec.AssertNotLogged(t, zapcore.DebugLevel, "ignored")
+	e := rec.AssertLogged(t, zapcore.ErrorLevel, "create user failed")
+	for key, want := range map[string]any{"module": "user", "requestID": "req-1", "id": int64(7), "error": "duplicate"} {
+		if got := logtest.Field(e, key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	if file := filepath.Base(e.Caller.File); file != "logx_test.go" {
+		t.Errorf("caller = %s, want logx_test.go", e.Caller.File)
+	}
+}
+
+func TestZapContextLogger(t *testing.T) {
+	base, _ := logtest.New(zapcore.InfoLevel)
+	logger := NewZap(base).With(String("module", "user"))
+
+	// ctx中携带的logger（如config.WithContext保存的）优先，With和WithContext的字段都保留
+	zl, rec := logtest.New(zapcore.InfoLevel)
+	ctx := WithZap(context.Background(), zl.With(zap.String("userID", "u-1")))
+	ctx = WithContext(ctx, String("requestID", "req-1"))
+	logger.Info(ctx, "user loaded", Int("id", 7))
+
+	e := rec.AssertLogged(t, zapcore.InfoLevel, "user loaded")
+	for key, want := range map[string]any{"userID": "u-1", "module": "user", "requestID": "req-1", "id": int64(7)} {
+		if got := logtest.Field(e, key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	if file := filepath.Base(e.Caller.File); file != "logx_test.go" {
+		t.Errorf("caller = %s, want logx_test.go", e.Caller.File)
+	}
+}
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+	sl := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true}))
+	logger := NewSlog(sl).With(String("module", "user"))
+
+	ctx := WithContext(context.Background(), String("requestID", "req-1"))
+	logger.Debug(ctx, "ignored")
+	logger.Warn(ctx, "slow query", Int("id", 7))
+
+	var got struct {
+		Level     string `json:"level"`
+		Msg       string `json:"msg"`
+		Module    string `json:"module"`
+		RequestID string `json:"requestID"`
+		ID        int    `json:"id"`
+		Source    struct {
+			File string `json:"file"`
+		} `json:"source"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if got.Level != "WARN" || got.Msg != "slow query" || got.Module != "user" || got.RequestID != "req-1" || got.ID != 7 {
+		t.Errorf("unexpected record %s", buf.String())
+	}
+	if file := filepath.Base(got.Source.File); file != "logx_test.go" {
+		t.Errorf("source = %s, want logx_test.go", got.Source.File)
+	}
+}
+
+func TestWithContextAppends(t *testing.T) {
+	ctx := WithContext(nil, String("a", "1"))
+	child := WithContext(ctx, String("b", "2"))
+
+	if got := ContextFields(ctx); len(got) != 1 {
+		t.Errorf("parent fields = %v, want 1 field", got)
+	}
+	if got := ContextFields(child); len(got) != 2 || got[0].Key != "a" || got[1].Key != "b" {
+		t.Errorf("child fields = %v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package logx
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// slogLogger 基于*slog.Logger的实现
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlog 把*slog.Logger包装为Logger，记录的PC指向调用logx的代码
+func NewSlog(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) Debug(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, slog.LevelDebug, msg, fields)
+}
+
+func (l *slogLogger) Info(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, slog.LevelInfo, msg, fields)
+}
+
+func (l *slogLogger) Warn(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, slog.LevelWarn, msg, fields)
+}
+
+func (l *slogLogger) Error(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, slog.LevelError, msg, fields)
+}
+
+func (l *slogLogger) With(fields ...Field) Logger {
+	attrs := slogAttrs(Merge(nil, fields))
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	return &slogLogger{logger: l.logger.With(args...)}
+}
+
+// log 与slog文档中包装函数的写法一致，跳过runtime.Callers、log和Debug/Info等方法
+func (l *slogLogger) log(ctx context.Context, level slog.Level, msg string, fields []Field) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.AddAttrs(slogAttrs(Merge(ctx, fields))...)
+	_ = l.logger.Handler().Handle(ctx, r)
+}
+
+func slogAttrs(fields []Field) []slog.Attr {
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(f.Key, f.Value)
+	}
+	return attrs
+}
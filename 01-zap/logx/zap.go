@@ -0,0 +1,84 @@
+package logx
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// zapLogger 基于*zap.Logger的实现
+// ctx中通过WithZap（如config.WithContext）携带了logger时使用它输出，With添加的字段同样带上
+type zapLogger struct {
+	logger *zap.Logger
+	fields []zap.Field // With添加的字段，使用ctx中的logger时追加
+}
+
+type zapKey struct{}
+
+// WithZap 返回携带logger的ctx，zap实现的Logger输出时优先使用它
+func WithZap(ctx context.Context, logger *zap.Logger) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, zapKey{}, logger)
+}
+
+// ZapFromContext 返回ctx中通过WithZap携带的logger
+func ZapFromContext(ctx context.Context) (*zap.Logger, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	logger, ok := ctx.Value(zapKey{}).(*zap.Logger)
+	return logger, ok && logger != nil
+}
+
+// NewZap 把*zap.Logger包装为Logger，调用者信息指向调用logx的代码
+func NewZap(logger *zap.Logger) Logger {
+	// Debug/Info等方法和log各占一层
+	return &zapLogger{logger: logger.WithOptions(zap.AddCallerSkip(2))}
+}
+
+func (l *zapLogger) Debug(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, zapcore.DebugLevel, msg, fields)
+}
+
+func (l *zapLogger) Info(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, zapcore.InfoLevel, msg, fields)
+}
+
+func (l *zapLogger) Warn(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, zapcore.WarnLevel, msg, fields)
+}
+
+func (l *zapLogger) Error(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, zapcore.ErrorLevel, msg, fields)
+}
+
+func (l *zapLogger) With(fields ...Field) Logger {
+	zf := zapFields(Merge(nil, fields))
+	return &zapLogger{
+		logger: l.logger.With(zf...),
+		fields: append(l.fields[:len(l.fields):len(l.fields)], zf...),
+	}
+}
+
+// log 先检查级别，未启用时不转换字段
+func (l *zapLogger) log(ctx context.Context, level zapcore.Level, msg string, fields []Field) {
+	logger, extra := l.logger, []zap.Field(nil)
+	if ctxLogger, ok := ZapFromContext(ctx); ok {
+		logger, extra = ctxLogger.WithOptions(zap.AddCallerSkip(2)), l.fields
+	}
+	if ce := logger.Check(level, msg); ce != nil {
+		ce.Write(append(extra[:len(extra):len(extra)], zapFields(Merge(ctx, fields))...)...)
+	}
+}
+
+// zapFields 转换为zap字段，error值通过zap.Any编码为zap.Error的格式
+func zapFields(fields []Field) []zap.Field {
+	out := make([]zap.Field, len(fields))
+	for i, f := range fields {
+		out[i] = zap.Any(f.Key, f.Value)
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package rotate
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// formatPattern 按strftime格式生成文件名，支持 %Y %y %m %d %H %M %S %j %%
+func formatPattern(pattern string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i == len(pattern)-1 {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'y':
+			fmt.Fprintf(&b, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(pattern[i])
+		}
+	}
+	return b.String()
+}
+
+// globPattern 把strftime格式转换为匹配所有轮转文件的glob
+func globPattern(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == '%' && i < len(pattern)-1 {
+			i++
+			if pattern[i] == '%' {
+				b.WriteByte('%')
+			} else {
+				b.WriteByte('*')
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	// 同一周期内按大小轮转的序号和压缩后缀
+	b.WriteByte('*')
+	return b.String()
+}
+
+// patternRegexp 把strftime格式转换为只匹配轮转文件的正则，每个时间格式对应固定位数的数字
+// glob中的*还会匹配 app-backup.log 这样不是由writer创建的文件，清理前需要再用它检查
+func patternRegexp(pattern string) *regexp.Regexp {
+	pattern = filepath.Clean(pattern)
+	var b strings.Builder
+	b.WriteByte('^')
+	lit := 0 // 还没有写入的普通字符的起始位置
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i == len(pattern)-1 {
+			continue
+		}
+		b.WriteString(regexp.QuoteMeta(pattern[lit:i]))
+		i++
+		switch pattern[i] {
+		case 'Y':
+			b.WriteString(`\d{4}`)
+		case 'y', 'm', 'd', 'H', 'M', 'S':
+			b.WriteString(`\d{2}`)
+		case 'j':
+			b.WriteString(`\d{3}`)
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i-1 : i+1]))
+		}
+		lit = i + 1
+	}
+	b.WriteString(regexp.QuoteMeta(pattern[lit:]))
+	// 同一周期内按大小轮转的序号和压缩后缀
+	b.WriteString(`(\.\d+)?(` + regexp.QuoteMeta(compressSuffix) + `)?$`)
+	return regexp.MustCompile(b.String())
+}
+
+// patternPeriod 按Pattern中最小的时间格式推导轮转间隔，如 %Y%m%d 为 24h，没有时间格式时返回0
+// 按月和年轮转没有固定的间隔，需要显式设置RotationTime
+func patternPeriod(pattern string) (time.Duration, error) {
+	var period time.Duration
+	calendar := false
+	for i := 0; i < len(pattern)-1; i++ {
+		if pattern[i] != '%' {
+			continue
+		}
+		i++
+		var d time.Duration
+		switch pattern[i] {
+		case 'S':
+			d = time.Second
+		case 'M':
+			d = time.Minute
+		case 'H':
+			d = time.Hour
+		case 'd', 'j':
+			d = 24 * time.Hour
+		case 'm', 'y', 'Y':
+			calendar = true
+		}
+		if d > 0 && (period == 0 || d < period) {
+			period = d
+		}
+	}
+	if period == 0 && calendar {
+		return 0, errors.New("rotate: pattern without day or smaller verbs requires RotationTime")
+	}
+	return period, nil
+}
@@ -0,0 +1,499 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options 轮转配置，大小和时间可以同时生效
+type Options struct {
+	Pattern      string           // strftime格式的文件名，如 logs/app.log.%Y%m%d
+	LinkName     string           // 指向当前文件的软链，为空不创建
+	MaxSize      int64            // 单个文件最大字节数，0 不按大小轮转
+	RotationTime time.Duration    // 按时间轮转的间隔，按本地时间对齐，为0时按Pattern中最小的时间格式推导，如 %Y%m%d 为 24h
+	MaxAge       time.Duration    // 轮转后的文件最多保存多久，0 不限制
+	MaxCount     int              // 轮转后的文件最多保留多少个，0 不限制
+	Compress     bool             // 轮转后在后台gzip压缩
+	FileMode     os.FileMode      // 新建文件的权限，默认 0644
+	Clock        func() time.Time // 获取当前时间，默认 time.Now
+}
+
+const compressSuffix = ".gz"
+
+// Writer 按大小和时间轮转的文件writer，可以直接作为zapcore.WriteSyncer使用
+type Writer struct {
+	opts Options
+
+	mu       sync.Mutex
+	file     *os.File
+	filename string    // 当前文件名
+	base     string    // 当前周期按Pattern生成的文件名
+	seq      int       // 同一周期内按大小轮转的序号
+	size     int64     // 当前文件大小
+	next     time.Time // 下一次按时间轮转的时间
+	closed   bool
+
+	// 后台任务不能获取mu：mu持有期间轮转可能阻塞在jobs上，后台任务读取的状态单独加锁
+	stateMu sync.Mutex
+	live    string              // 当前正在写入的文件名
+	pending map[string]struct{} // 已经轮转、还没有压缩的文件，清理时计数但不删除
+
+	jobs chan job // 需要压缩和清理的旧文件
+	done chan struct{}
+}
+
+// New 创建轮转writer，会打开当前周期最新的文件继续追加
+func New(opts Options) (*Writer, error) {
+	if opts.Pattern == "" {
+		return nil, errors.New("rotate: pattern is required")
+	}
+	if opts.FileMode == 0 {
+		opts.FileMode = 0o644
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+	if opts.RotationTime <= 0 {
+		// 只设置了按日期命名的Pattern时同样按时间轮转
+		period, err := patternPeriod(opts.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		opts.RotationTime = period
+	}
+
+	w := &Writer{
+		opts:    opts,
+		pending: make(map[string]struct{}),
+		jobs:    make(chan job, 16),
+		done:    make(chan struct{}),
+	}
+	go w.runJobs()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.openExisting(opts.Clock()); err != nil {
+		close(w.jobs)
+		<-w.done
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write 写入前检查是否需要轮转
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	now := w.opts.Clock()
+	switch {
+	case w.opts.RotationTime > 0 && !now.Before(w.next):
+		if err := w.rotate(now, false); err != nil {
+			return 0, err
+		}
+	case w.opts.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.opts.MaxSize:
+		if err := w.rotate(now, true); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Sync 把文件内容刷到磁盘
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close 关闭当前文件，等待后台压缩和清理完成
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	err := w.closeFile()
+	w.mu.Unlock()
+
+	close(w.jobs)
+	<-w.done
+	return err
+}
+
+// Rotate 立即切换到新文件
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	now := w.opts.Clock()
+	return w.rotate(now, formatPattern(w.opts.Pattern, w.periodStart(now)) == w.base)
+}
+
+// Reopen 重新打开当前文件名，用于logrotate移走文件之后
+func (w *Writer) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	return w.open(w.filename)
+}
+
+// Filename 返回当前正在写入的文件名
+func (w *Writer) Filename() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.filename
+}
+
+// Files 返回所有轮转文件（包括当前文件），按写入先后排序
+func (w *Writer) Files() ([]string, error) {
+	return Files(w.opts.Pattern, w.opts.LinkName)
+}
+
+// Files 返回Pattern对应的所有轮转文件，按写入先后排序
+func Files(pattern, linkName string) ([]string, error) {
+	infos, err := list(pattern, linkName)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, len(infos))
+	for i, info := range infos {
+		files[i] = info.path
+	}
+	return files, nil
+}
+
+// periodStart 当前时间所在周期的开始时间，按本地时区对齐
+func (w *Writer) periodStart(now time.Time) time.Time {
+	if w.opts.RotationTime <= 0 {
+		return now
+	}
+	_, offset := now.Zone()
+	shift := time.Duration(offset) * time.Second
+	return now.Add(shift).Truncate(w.opts.RotationTime).Add(-shift)
+}
+
+// openExisting 打开当前周期序号最大的文件继续写入
+func (w *Writer) openExisting(now time.Time) error {
+	start := w.periodStart(now)
+	w.base = formatPattern(w.opts.Pattern, start)
+	w.next = start.Add(w.opts.RotationTime)
+
+	w.seq = 0
+	for exists(seqName(w.base, w.seq+1)) {
+		w.seq++
+	}
+
+	// 已经压缩或者写满的文件不再追加
+	filename := seqName(w.base, w.seq)
+	info, err := os.Stat(filename)
+	if (err != nil && exists(filename)) || (err == nil && w.opts.MaxSize > 0 && info.Size() >= w.opts.MaxSize) {
+		w.seq++
+		filename = seqName(w.base, w.seq)
+	}
+	return w.open(filename)
+}
+
+// exists 判断文件或其压缩文件是否存在
+func exists(filename string) bool {
+	if _, err := os.Stat(filename); err == nil {
+		return true
+	}
+	_, err := os.Stat(filename + compressSuffix)
+	return err == nil
+}
+
+// rotate 关闭当前文件并打开新文件，samePeriod为true时在同一周期内递增序号
+func (w *Writer) rotate(now time.Time, samePeriod bool) error {
+	old := w.filename
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	if samePeriod {
+		w.seq++
+		for exists(seqName(w.base, w.seq)) {
+			w.seq++
+		}
+		if err := w.open(seqName(w.base, w.seq)); err != nil {
+			return err
+		}
+	} else if err := w.openExisting(now); err != nil {
+		return err
+	}
+
+	if old != "" && old != w.filename {
+		w.stateMu.Lock()
+		w.pending[old] = struct{}{}
+		w.stateMu.Unlock()
+		w.jobs <- job{old: old}
+	}
+	return nil
+}
+
+// open 以追加方式打开文件并更新软链
+func (w *Writer) open(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return fmt.Errorf("rotate: create log dir: %w", err)
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, w.opts.FileMode)
+	if err != nil {
+		return fmt.Errorf("rotate: open %s: %w", filename, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("rotate: stat %s: %w", filename, err)
+	}
+
+	w.file = f
+	w.filename = filename
+	w.size = info.Size()
+
+	w.stateMu.Lock()
+	w.live = filename
+	w.stateMu.Unlock()
+
+	if w.opts.LinkName != "" {
+		// 软链失败（如Windows没有权限）不影响写日志
+		_ = link(filename, w.opts.LinkName)
+	}
+	return nil
+}
+
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// seqName 同一周期内的第seq个文件，第0个不带序号
+func seqName(base string, seq int) string {
+	if seq == 0 {
+		return base
+	}
+	return fmt.Sprintf("%s.%d", base, seq)
+}
+
+// link 原子地把软链指向target
+func link(target, linkName string) error {
+	dest, err := filepath.Rel(filepath.Dir(linkName), target)
+	if err != nil {
+		if dest, err = filepath.Abs(target); err != nil {
+			return err
+		}
+	}
+	tmp := linkName + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(dest, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, linkName)
+}
+
+// job 轮转后需要在后台处理的文件
+type job struct {
+	old string // 刚轮转出去的文件
+}
+
+// runJobs 后台压缩旧文件并清理过期文件
+func (w *Writer) runJobs() {
+	defer close(w.done)
+
+	for j := range w.jobs {
+		if w.opts.Compress {
+			// 压缩失败时保留原文件
+			_ = compress(j.old)
+		}
+		w.stateMu.Lock()
+		delete(w.pending, j.old)
+		w.stateMu.Unlock()
+		_ = w.prune()
+	}
+}
+
+// compress 把文件压缩为.gz并删除原文件
+func compress(filename string) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := filename + compressSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, filename+compressSuffix); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	os.Chtimes(filename+compressSuffix, info.ModTime(), info.ModTime())
+	return os.Remove(filename)
+}
+
+// prune 按MaxCount和MaxAge删除旧文件
+// 任务在后台执行，期间可能又轮转了多次：当前文件按执行时的文件名跳过，
+// 排队等待压缩的文件计入MaxCount但不删除，由它们自己的任务压缩后再清理
+func (w *Writer) prune() error {
+	if w.opts.MaxCount <= 0 && w.opts.MaxAge <= 0 {
+		return nil
+	}
+
+	w.stateMu.Lock()
+	current := w.live
+	pending := make(map[string]struct{}, len(w.pending))
+	for name := range w.pending {
+		pending[name] = struct{}{}
+	}
+	w.stateMu.Unlock()
+
+	infos, err := list(w.opts.Pattern, w.opts.LinkName)
+	if err != nil {
+		return err
+	}
+
+	rotated := infos[:0]
+	for _, info := range infos {
+		if info.path != current {
+			rotated = append(rotated, info)
+		}
+	}
+
+	cutoff := w.opts.Clock().Add(-w.opts.MaxAge)
+	var errs []error
+	for i, info := range rotated {
+		// rotated按从旧到新排列
+		if _, ok := pending[info.path]; ok {
+			continue
+		}
+		tooMany := w.opts.MaxCount > 0 && len(rotated)-i > w.opts.MaxCount
+		tooOld := w.opts.MaxAge > 0 && info.modTime.Before(cutoff)
+		if tooMany || tooOld {
+			if err := os.Remove(info.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type fileInfo struct {
+	path    string
+	modTime time.Time
+}
+
+// list 列出所有轮转文件，按修改时间从旧到新排序
+// 只返回文件名符合Pattern的文件，同一目录中的其它文件不会被清理
+func list(pattern, linkName string) ([]fileInfo, error) {
+	matches, err := filepath.Glob(globPattern(pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	re := patternRegexp(pattern)
+	var infos []fileInfo
+	for _, path := range matches {
+		if path == linkName || !re.MatchString(filepath.Clean(path)) {
+			continue
+		}
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		infos = append(infos, fileInfo{path: path, modTime: info.ModTime()})
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		if !infos[i].modTime.Equal(infos[j].modTime) {
+			return infos[i].modTime.Before(infos[j].modTime)
+		}
+		// 同一时钟刻度内轮转的文件修改时间相同，按序号排序
+		return naturalLess(strings.TrimSuffix(infos[i].path, compressSuffix), strings.TrimSuffix(infos[j].path, compressSuffix))
+	})
+	return infos, nil
+}
+
+// naturalLess 按自然顺序比较文件名，数字部分按数值比较，如 app.log.2 < app.log.10
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := digitPrefix(a), digitPrefix(b)
+		if da != "" && db != "" {
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// digitPrefix 返回开头的连续数字
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
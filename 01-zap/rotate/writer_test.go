@@ -0,0 +1,294 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedClock 可以手动调整的时钟
+type fixedClock struct{ now time.Time }
+
+func (c *fixedClock) Now() time.Time { return c.now }
+
+func newTestWriter(t *testing.T, opts Options) (*Writer, string) {
+	t.Helper()
+	dir := t.TempDir()
+	opts.Pattern = filepath.Join(dir, "app.log.%Y%m%d")
+	opts.LinkName = filepath.Join(dir, "app.log")
+	w, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, dir
+}
+
+// names 返回目录中的文件名，不含软链
+func names(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, e := range entries {
+		if e.Name() != "app.log" {
+			out = append(out, e.Name())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return naturalLess(strings.TrimSuffix(out[i], compressSuffix), strings.TrimSuffix(out[j], compressSuffix))
+	})
+	return out
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, compressSuffix) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotateBySize(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2025, 1, 17, 10, 0, 0, 0, time.Local)}
+	w, dir := newTestWriter(t, Options{MaxSize: 100, Clock: clock.Now})
+
+	line := strings.Repeat("x", 39) + "\n"
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app.log.20250117", "app.log.20250117.1", "app.log.20250117.2"}
+	if got := names(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log.20250117")); got != line+line {
+		t.Fatalf("first file = %q", got)
+	}
+	if target, _ := os.Readlink(filepath.Join(dir, "app.log")); target != "app.log.20250117.2" {
+		t.Fatalf("link = %q", target)
+	}
+}
+
+func TestRotateByTime(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2025, 1, 17, 23, 59, 0, 0, time.Local)}
+	w, dir := newTestWriter(t, Options{RotationTime: 24 * time.Hour, Clock: clock.Now})
+
+	w.Write([]byte("day1\n"))
+	clock.now = clock.now.Add(2 * time.Minute)
+	w.Write([]byte("day2\n"))
+	if got := filepath.Base(w.Filename()); got != "app.log.20250118" {
+		t.Fatalf("Filename() = %q", got)
+	}
+	w.Close()
+
+	if got := readFile(t, filepath.Join(dir, "app.log.20250117")); got != "day1\n" {
+		t.Fatalf("day1 = %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log.20250118")); got != "day2\n" {
+		t.Fatalf("day2 = %q", got)
+	}
+}
+
+// 只设置了按日期命名的Pattern时按Pattern推导轮转间隔
+func TestRotateByPatternPeriod(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2025, 1, 17, 23, 59, 0, 0, time.Local)}
+	w, _ := newTestWriter(t, Options{Clock: clock.Now})
+	defer w.Close()
+
+	w.Write([]byte("day1\n"))
+	clock.now = clock.now.Add(2 * time.Minute)
+	w.Write([]byte("day2\n"))
+	if got := filepath.Base(w.Filename()); got != "app.log.20250118" {
+		t.Fatalf("Filename() = %q", got)
+	}
+}
+
+func TestPatternPeriod(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    time.Duration
+		err     bool
+	}{
+		{"app.log", 0, false},
+		{"app-%Y%m%d.log", 24 * time.Hour, false},
+		{"app.%j", 24 * time.Hour, false},
+		{"app.log.%Y%m%d%H", time.Hour, false},
+		{"app.log.%H%M", time.Minute, false},
+		{"app.log.%Y%m%d%H%M%S", time.Second, false},
+		{"100%%.log", 0, false},
+		{"app.log.%Y%m", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := patternPeriod(tt.pattern)
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("patternPeriod(%q) = %v, %v", tt.pattern, got, err)
+		}
+	}
+	if _, err := New(Options{Pattern: filepath.Join(t.TempDir(), "app.log.%Y%m")}); err == nil {
+		t.Error("New with a monthly pattern and no RotationTime: want error")
+	}
+}
+
+func TestCompress(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2025, 1, 17, 10, 0, 0, 0, time.Local)}
+	w, dir := newTestWriter(t, Options{MaxSize: 10, Compress: true, Clock: clock.Now})
+
+	w.Write([]byte("first log\n"))
+	w.Write([]byte("second\n"))
+	w.Close()
+
+	want := []string{"app.log.20250117.gz", "app.log.20250117.1"}
+	if got := names(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log.20250117.gz")); got != "first log\n" {
+		t.Fatalf("compressed = %q", got)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	w, dir := newTestWriter(t, Options{MaxSize: 10, MaxAge: 7 * 24 * time.Hour})
+
+	old := filepath.Join(dir, "app.log.20240101")
+	if err := os.WriteFile(old, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-10 * 24 * time.Hour)
+	os.Chtimes(old, mtime, mtime)
+
+	w.Write([]byte("first log\n"))
+	w.Write([]byte("second\n"))
+	w.Close()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Fatalf("expired file not removed: %v", err)
+	}
+	if got := len(names(t, dir)); got != 2 {
+		t.Fatalf("files = %v", names(t, dir))
+	}
+}
+
+// 同一目录中glob能匹配、但不是由writer创建的文件不能被清理
+func TestPruneOnlyRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fixedClock{now: time.Date(2025, 1, 17, 10, 0, 0, 0, time.Local)}
+	w, err := New(Options{
+		Pattern:  filepath.Join(dir, "app-%Y%m%d.log"),
+		MaxSize:  10,
+		MaxCount: 1,
+		MaxAge:   24 * time.Hour,
+		Clock:    clock.Now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	others := []string{"app-backup.log", "app-2025.log", "app-20250117.log.bak", "app-20250117.log.gz.tmp"}
+	mtime := clock.now.Add(-30 * 24 * time.Hour)
+	for _, name := range others {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("keep\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mtime, mtime)
+	}
+
+	for i := 0; i < 4; i++ {
+		w.Write([]byte("0123456789\n"))
+	}
+	w.Close()
+
+	for _, name := range others {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+	files, err := w.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"app-20250117.log.2", "app-20250117.log.3"}
+	var got []string
+	for _, f := range files {
+		got = append(got, filepath.Base(f))
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Files() = %v, want %v", got, want)
+	}
+}
+
+func TestPatternRegexp(t *testing.T) {
+	re := patternRegexp("logs/./app-%Y%m%d.%j%%.log")
+	for name, want := range map[string]bool{
+		"logs/app-20250117.017%.log":      true,
+		"logs/app-20250117.017%.log.3":    true,
+		"logs/app-20250117.017%.log.3.gz": true,
+		"logs/app-2025017.017%.log":       false,
+		"logs/app-backup.017%.log":        false,
+		"logs/app-20250117.017%.log.bak":  false,
+	} {
+		if got := re.MatchString(name); got != want {
+			t.Errorf("match %q = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// 后台任务落后于轮转时，当前文件和排队压缩的文件不能被清理
+func TestMaxCountWithPendingCompression(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2025, 1, 17, 10, 0, 0, 0, time.Local)}
+	w, dir := newTestWriter(t, Options{MaxSize: 100, MaxCount: 2, Compress: true, Clock: clock.Now})
+
+	line := strings.Repeat("x", 59) + "\n"
+	for i := 0; i < 12; i++ {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	live := filepath.Base(w.Filename())
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app.log.20250117.9.gz", "app.log.20250117.10.gz", live}
+	if got := names(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, live)); got != line {
+		t.Fatalf("live file = %q", got)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	ordered := []string{"app.log.20250117", "app.log.20250117.1", "app.log.20250117.2", "app.log.20250117.10", "app.log.20250118"}
+	for i := 0; i < len(ordered)-1; i++ {
+		if !naturalLess(ordered[i], ordered[i+1]) || naturalLess(ordered[i+1], ordered[i]) {
+			t.Errorf("%s < %s", ordered[i], ordered[i+1])
+		}
+	}
+}
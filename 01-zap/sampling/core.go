@@ -0,0 +1,105 @@
+package sampling
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 汇总日志的字段
+const (
+	SuppressedKey = "suppressed"  // 被丢弃的条数
+	MessageKey    = "sampled_msg" // 被丢弃日志的消息
+)
+
+// SummaryMessage 汇总日志的消息
+func SummaryMessage(n uint64) string {
+	return fmt.Sprintf("suppressed %d similar entries", n)
+}
+
+// core 采样的zapcore.Core，Check时按消息采样，Write时按字段值限流
+type core struct {
+	zapcore.Core
+	sampler *Sampler
+	value   string // With中LimitField字段的值
+	limited bool
+}
+
+// NewCore 包装core，返回的Sampler需要在退出前Close，输出最后一次汇总
+// 汇总日志使用被丢弃日志的级别，通过原来的core写入，不受采样影响
+//
+//	core, sampler := sampling.NewCore(core, sampling.Options{Initial: 100, Thereafter: 100})
+//	defer sampler.Close()
+func NewCore(c zapcore.Core, opts Options) (zapcore.Core, *Sampler) {
+	clock := opts.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	s := New(opts, func(sup Suppressed) {
+		level, err := zapcore.ParseLevel(sup.Level)
+		if err != nil {
+			return
+		}
+		ent := zapcore.Entry{Level: level, Time: clock(), Message: SummaryMessage(sup.Count)}
+		if ce := c.Check(ent, nil); ce != nil {
+			ce.Write(zap.String(MessageKey, sup.Message), zap.Uint64(SuppressedKey, sup.Count))
+		}
+	})
+	return &core{Core: c, sampler: s}, s
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	clone := &core{Core: c.Core.With(fields), sampler: c.sampler, value: c.value, limited: c.limited}
+	if v, ok := limitValue(c.sampler.opts.LimitField, fields); ok {
+		clone.value, clone.limited = v, true
+	}
+	return clone
+}
+
+// Check 级别未启用的日志不计数
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if !c.sampler.Sample(ent.Level.String(), ent.Message) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+// Write 限流后交给原来的core，由其按各个输出的级别范围写入
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	value, limited := c.value, c.limited
+	if v, ok := limitValue(c.sampler.opts.LimitField, fields); ok {
+		value, limited = v, true
+	}
+	if limited && !c.sampler.Limit(ent.Level.String(), ent.Message, value) {
+		return nil
+	}
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// limitValue 在字段中查找LimitField，返回字符串形式的值
+func limitValue(key string, fields []zapcore.Field) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		if f.Key != key {
+			continue
+		}
+		if f.Type == zapcore.StringType {
+			return f.String, true
+		}
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		return fmt.Sprint(enc.Fields[key]), true
+	}
+	return "", false
+}
@@ -0,0 +1,234 @@
+// Package sampling 高频日志的采样和限流，zap和logrus共用同一套计数
+//
+// 每个Tick内同一级别同一消息前Initial条全部输出，之后每Thereafter条输出一条；
+// 设置LimitField时再按该字段的值（如userID）使用令牌桶限流。
+// 被丢弃的日志按消息计数，每隔SummaryInterval输出一条 "suppressed N similar entries"，不会悄悄消失。
+package sampling
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Options 采样和限流配置
+type Options struct {
+	Tick            time.Duration    `yaml:"tick"`             // 计数周期，默认 1s
+	Initial         int              `yaml:"initial"`          // 每个周期内同一消息先全部输出的条数
+	Thereafter      int              `yaml:"thereafter"`       // 之后每Thereafter条输出一条，Initial和Thereafter都为0时不按消息采样
+	LimitField      string           `yaml:"limit_field"`      // 按该字段的值分别限流，如 userID，为空不限流
+	Rate            float64          `yaml:"rate"`             // 每个字段值每秒允许的条数
+	Burst           int              `yaml:"burst"`            // 令牌桶容量，默认为Rate向上取整
+	SummaryInterval time.Duration    `yaml:"summary_interval"` // 输出被丢弃条数的间隔，默认 10s
+	MaxKeys         int              `yaml:"max_keys"`         // 最多跟踪的字段值个数，默认 10000，超过后新的值不限流
+	Clock           func() time.Time `yaml:"-"`                // 获取当前时间，默认 time.Now
+}
+
+const (
+	defaultTick            = time.Second
+	defaultSummaryInterval = 10 * time.Second
+	defaultMaxKeys         = 10000
+)
+
+// Key 按级别和消息计数
+type Key struct {
+	Level   string
+	Message string
+}
+
+// Suppressed 一个汇总周期内被丢弃的日志
+type Suppressed struct {
+	Key
+	Count uint64
+}
+
+// Sampler 记录每条消息和每个字段值的输出次数，可以被多个goroutine同时使用
+type Sampler struct {
+	opts   Options
+	report func(Suppressed)
+
+	mu         sync.Mutex
+	tickEnd    time.Time
+	counts     map[Key]uint64
+	buckets    map[string]*bucket
+	suppressed map[Key]uint64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// bucket 令牌桶，tokens按Rate随时间恢复
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New 创建Sampler，report不为空时每隔SummaryInterval报告被丢弃的日志，Close时报告剩余的部分
+func New(opts Options, report func(Suppressed)) *Sampler {
+	if opts.Tick <= 0 {
+		opts.Tick = defaultTick
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = int(math.Ceil(opts.Rate))
+	}
+	if opts.SummaryInterval <= 0 {
+		opts.SummaryInterval = defaultSummaryInterval
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = defaultMaxKeys
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	s := &Sampler{
+		opts:       opts,
+		report:     report,
+		counts:     make(map[Key]uint64),
+		buckets:    make(map[string]*bucket),
+		suppressed: make(map[Key]uint64),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if report != nil {
+		go s.run()
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+// Options 返回补全默认值之后的配置
+func (s *Sampler) Options() Options {
+	return s.opts
+}
+
+// Sample 按消息采样，返回false表示丢弃
+func (s *Sampler) Sample(level, msg string) bool {
+	if s.opts.Initial <= 0 && s.opts.Thereafter <= 0 {
+		return true
+	}
+	key := Key{Level: level, Message: msg}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.opts.Clock()
+	if !now.Before(s.tickEnd) {
+		clear(s.counts)
+		s.tickEnd = now.Add(s.opts.Tick)
+	}
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= uint64(s.opts.Initial) {
+		return true
+	}
+	if s.opts.Thereafter > 0 && (n-uint64(s.opts.Initial))%uint64(s.opts.Thereafter) == 0 {
+		return true
+	}
+	s.suppressed[key]++
+	return false
+}
+
+// Limit 按字段值限流，返回false表示丢弃；没有配置LimitField和Rate时总是返回true
+func (s *Sampler) Limit(level, msg, value string) bool {
+	if s.opts.LimitField == "" || s.opts.Rate <= 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.opts.Clock()
+	b, ok := s.buckets[value]
+	if !ok {
+		// 跟踪的值太多时不再限流，避免内存无限增长
+		if len(s.buckets) >= s.opts.MaxKeys {
+			return true
+		}
+		b = &bucket{tokens: float64(s.opts.Burst), last: now}
+		s.buckets[value] = b
+	}
+	b.refill(now, s.opts.Rate, float64(s.opts.Burst))
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	s.suppressed[Key{Level: level, Message: msg}]++
+	return false
+}
+
+func (b *bucket) refill(now time.Time, rate, burst float64) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed.Seconds()*rate)
+		b.last = now
+	}
+}
+
+// Flush 立即报告被丢弃的日志，并清理已经恢复满的令牌桶
+func (s *Sampler) Flush() {
+	for _, sup := range s.drain() {
+		if s.report != nil {
+			s.report(sup)
+		}
+	}
+}
+
+// drain 取出被丢弃的计数，按级别和消息排序
+func (s *Sampler) drain() []Suppressed {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.opts.Clock()
+	for value, b := range s.buckets {
+		b.refill(now, s.opts.Rate, float64(s.opts.Burst))
+		if b.tokens >= float64(s.opts.Burst) {
+			delete(s.buckets, value)
+		}
+	}
+
+	if len(s.suppressed) == 0 {
+		return nil
+	}
+	out := make([]Suppressed, 0, len(s.suppressed))
+	for key, n := range s.suppressed {
+		out = append(out, Suppressed{Key: key, Count: n})
+	}
+	clear(s.suppressed)
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Level != out[j].Level {
+			return out[i].Level < out[j].Level
+		}
+		return out[i].Message < out[j].Message
+	})
+	return out
+}
+
+// run 定期报告被丢弃的日志
+func (s *Sampler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.opts.SummaryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Close 停止定期报告，并报告剩余的被丢弃日志
+func (s *Sampler) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		s.Flush()
+	})
+	return nil
+}
@@ -0,0 +1,145 @@
+package sampling
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeClock 测试中手动推进的时间
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 17, 10, 0, 0, 0, time.Local)}
+}
+
+func TestSampleFirstThenEvery(t *testing.T) {
+	clock := newClock()
+	var reports []Suppressed
+	s := New(Options{Tick: time.Second, Initial: 2, Thereafter: 3, SummaryInterval: time.Hour, Clock: clock.Now}, func(sup Suppressed) {
+		reports = append(reports, sup)
+	})
+	defer s.Close()
+
+	var kept []int
+	for i := 1; i <= 10; i++ {
+		if s.Sample("info", "performance test") {
+			kept = append(kept, i)
+		}
+	}
+	if want := []int{1, 2, 5, 8}; !slices.Equal(kept, want) {
+		t.Errorf("kept = %v, want %v", kept, want)
+	}
+
+	// 其它消息单独计数
+	if !s.Sample("info", "other") {
+		t.Error("first entry of another message was dropped")
+	}
+
+	// 下一个周期重新计数
+	clock.Add(time.Second)
+	if !s.Sample("info", "performance test") {
+		t.Error("first entry of the next tick was dropped")
+	}
+
+	s.Flush()
+	if len(reports) != 1 || reports[0].Message != "performance test" || reports[0].Count != 6 {
+		t.Fatalf("reports = %+v, want 6 suppressed performance test", reports)
+	}
+	s.Flush()
+	if len(reports) != 1 {
+		t.Errorf("suppressed counts were not reset: %+v", reports)
+	}
+}
+
+func TestLimitPerValue(t *testing.T) {
+	clock := newClock()
+	s := New(Options{LimitField: "userID", Rate: 2, Burst: 2, Clock: clock.Now}, nil)
+	defer s.Close()
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if s.Limit("info", "request", "u1") {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("u1 allowed %d entries, want burst 2", allowed)
+	}
+	if !s.Limit("info", "request", "u2") {
+		t.Error("u2 should have its own bucket")
+	}
+
+	clock.Add(500 * time.Millisecond)
+	if !s.Limit("info", "request", "u1") {
+		t.Error("bucket did not refill after 500ms at 2/s")
+	}
+	if s.Limit("info", "request", "u1") {
+		t.Error("bucket refilled more than one token")
+	}
+}
+
+func TestCore(t *testing.T) {
+	clock := newClock()
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core, sampler := NewCore(obs, Options{
+		Initial:         1,
+		Thereafter:      0,
+		LimitField:      "userID",
+		Rate:            1,
+		SummaryInterval: time.Hour,
+		Clock:           clock.Now,
+	})
+	logger := zap.New(core)
+
+	for i := 0; i < 5; i++ {
+		logger.Info("performance test", zap.Int("iteration", i))
+	}
+
+	// 通过With和调用时的字段限流
+	user := logger.With(zap.String("userID", "u1"))
+	user.Warn("slow request")
+	clock.Add(2 * time.Second)
+	user.Warn("slow request")
+	clock.Add(2 * time.Second)
+	logger.Warn("slow request", zap.String("userID", "u1"))
+
+	if got := logs.FilterMessage("performance test").Len(); got != 1 {
+		t.Errorf("performance test logged %d times, want 1", got)
+	}
+	if got := logs.FilterMessage("slow request").Len(); got != 3 {
+		t.Errorf("slow request logged %d times, want 3", got)
+	}
+
+	if err := sampler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	summary := logs.FilterMessage(SummaryMessage(4)).All()
+	if len(summary) != 1 {
+		t.Fatalf("no summary entry, got %v", logs.All())
+	}
+	fields := summary[0].ContextMap()
+	if summary[0].Level != zapcore.InfoLevel || fields[MessageKey] != "performance test" || fields[SuppressedKey] != uint64(4) {
+		t.Errorf("unexpected summary %v %v", summary[0].Level, fields)
+	}
+}
@@ -0,0 +1,130 @@
+package ship
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const queueSuffix = ".ndjson"
+
+// diskQueue 发送失败的批次按文件保存在目录中，文件名按写入顺序排序
+type diskQueue struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	files []queueFile
+	size  int64
+	seq   uint64
+}
+
+type queueFile struct {
+	name string
+	size int64
+}
+
+// openQueue 打开队列目录，加载上次运行没有发送的批次
+func openQueue(dir string, maxBytes int64) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ship: create queue dir: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("ship: read queue dir: %w", err)
+	}
+
+	q := &diskQueue{dir: dir, maxBytes: maxBytes}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, queueSuffix) {
+			// 写入过程中退出留下的临时文件
+			if strings.HasSuffix(name, ".tmp") {
+				os.Remove(filepath.Join(dir, name))
+			}
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		q.files = append(q.files, queueFile{name: name, size: info.Size()})
+		q.size += info.Size()
+	}
+	sort.Slice(q.files, func(i, j int) bool { return q.files[i].name < q.files[j].name })
+	return q, nil
+}
+
+// Len 返回队列中的批次数
+func (q *diskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.files)
+}
+
+// push 保存一个批次，超过maxBytes时删除最早的批次，返回删除的批次数
+func (q *diskQueue) push(data []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), q.seq%1000000, queueSuffix)
+	path := filepath.Join(q.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("ship: write queue file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("ship: write queue file: %w", err)
+	}
+	q.files = append(q.files, queueFile{name: name, size: int64(len(data))})
+	q.size += int64(len(data))
+
+	evicted := 0
+	for q.maxBytes > 0 && q.size > q.maxBytes && len(q.files) > 1 {
+		q.removeLocked(q.files[0].name)
+		evicted++
+	}
+	return evicted, nil
+}
+
+// peek 读取最早的批次
+func (q *diskQueue) peek() (string, []byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.files) > 0 {
+		name := q.files[0].name
+		data, err := os.ReadFile(filepath.Join(q.dir, name))
+		if os.IsNotExist(err) {
+			q.removeLocked(name)
+			continue
+		}
+		return name, data, err
+	}
+	return "", nil, nil
+}
+
+// remove 删除发送成功的批次
+func (q *diskQueue) remove(name string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.removeLocked(name)
+}
+
+func (q *diskQueue) removeLocked(name string) {
+	for i, f := range q.files {
+		if f.name == name {
+			os.Remove(filepath.Join(q.dir, name))
+			q.size -= f.size
+			q.files = append(q.files[:i], q.files[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,430 @@
+// Package ship 把日志批量发送到HTTP收集端
+//
+// Writer 实现 io.Writer 和 zapcore.WriteSyncer，每次Write是一条JSON日志，
+// 可以作为zap的输出目标，也可以作为logrus的输出或Hook使用。
+// 日志按条数、大小和时间合并为NDJSON批次，gzip压缩后POST到收集端，失败时按指数退避重试；
+// 收集端不可用时批次写入本地磁盘队列，恢复后按顺序重放。
+package ship
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options 发送配置
+type Options struct {
+	Endpoint      string            `yaml:"endpoint"`        // 收集端地址
+	Headers       map[string]string `yaml:"headers"`         // 额外的请求头，如 Authorization
+	Gzip          bool              `yaml:"gzip"`            // 是否gzip压缩请求体
+	BatchSize     int               `yaml:"batch_size"`      // 每批最多多少条，默认 500
+	BatchBytes    int               `yaml:"batch_bytes"`     // 每批最多多少字节，默认 1MB
+	FlushInterval time.Duration     `yaml:"flush_interval"`  // 不满一批时的发送间隔，默认 1s
+	BufferSize    int               `yaml:"buffer_size"`     // 内存中最多缓存多少条，满了之后丢弃，默认 10000
+	Timeout       time.Duration     `yaml:"timeout"`         // 单次请求超时，默认 10s
+	MaxRetries    int               `yaml:"max_retries"`     // 写入磁盘队列之前的重试次数，默认 3
+	MinBackoff    time.Duration     `yaml:"min_backoff"`     // 第一次重试的等待时间，默认 100ms
+	MaxBackoff    time.Duration     `yaml:"max_backoff"`     // 重试等待时间上限，默认 30s
+	QueueDir      string            `yaml:"queue_dir"`       // 磁盘队列目录，为空时发送失败的批次直接丢弃
+	MaxQueueBytes int64             `yaml:"max_queue_bytes"` // 磁盘队列最大字节数，超过时删除最早的批次，默认 100MB
+
+	Client  *http.Client `yaml:"-"` // 默认使用带Timeout的http.Client
+	OnError func(error)  `yaml:"-"` // 发送失败时的回调，默认输出到stderr，不能再写入本Writer
+}
+
+const (
+	defaultBatchSize     = 500
+	defaultBatchBytes    = 1 << 20
+	defaultFlushInterval = time.Second
+	defaultBufferSize    = 10000
+	defaultTimeout       = 10 * time.Second
+	defaultMaxRetries    = 3
+	defaultMinBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff    = 30 * time.Second
+	defaultMaxQueueBytes = 100 << 20
+)
+
+// ErrClosed Writer关闭后写入返回的错误
+var ErrClosed = errors.New("ship: writer is closed")
+
+// Stats 发送统计
+type Stats struct {
+	Sent    uint64 // 发送成功的条数
+	Dropped uint64 // 丢弃的条数：内存缓存已满、收集端拒绝或没有磁盘队列时发送失败
+	Spilled uint64 // 写入磁盘队列的批次数
+	Evicted uint64 // 磁盘队列超过上限被删除的批次数
+	Queued  int    // 磁盘队列中等待重放的批次数
+}
+
+// Writer 批量发送日志的writer
+type Writer struct {
+	opts   Options
+	client *http.Client
+	queue  *diskQueue
+
+	entries chan []byte
+	syncs   chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+
+	closeOnce sync.Once
+	closed    atomic.Bool
+
+	sent, dropped, spilled, evicted atomic.Uint64
+
+	// 以下字段只在后台goroutine中访问
+	batch     [][]byte
+	batchSize int
+	backoff   time.Duration
+	replay    <-chan time.Time
+}
+
+// New 创建Writer并启动后台发送
+func New(opts Options) (*Writer, error) {
+	if opts.Endpoint == "" {
+		return nil, errors.New("ship: endpoint is required")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.BatchBytes <= 0 {
+		opts.BatchBytes = defaultBatchBytes
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultBufferSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	} else if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+	if opts.MaxQueueBytes <= 0 {
+		opts.MaxQueueBytes = defaultMaxQueueBytes
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(err error) { fmt.Fprintln(os.Stderr, err) }
+	}
+
+	w := &Writer{
+		opts:    opts,
+		client:  opts.Client,
+		entries: make(chan []byte, opts.BufferSize),
+		syncs:   make(chan chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		backoff: opts.MinBackoff,
+	}
+	if w.client == nil {
+		w.client = &http.Client{Timeout: opts.Timeout}
+	}
+	if opts.QueueDir != "" {
+		q, err := openQueue(opts.QueueDir, opts.MaxQueueBytes)
+		if err != nil {
+			return nil, err
+		}
+		w.queue = q
+		// 重放上次运行没有发送成功的批次
+		if q.Len() > 0 {
+			w.replay = time.After(0)
+		}
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Write 把一条或多条（按换行分隔）日志放入内存缓存，缓存满时丢弃，不会阻塞调用方
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed.Load() {
+		return 0, ErrClosed
+	}
+
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		// 调用方会复用p，这里需要复制
+		entry := make([]byte, len(line)+1)
+		copy(entry, line)
+		entry[len(line)] = '\n'
+
+		select {
+		case w.entries <- entry:
+		default:
+			w.dropped.Add(1)
+		}
+	}
+	return len(p), nil
+}
+
+// Sync 立即发送缓存中的日志，收集端不可用时写入磁盘队列后返回
+func (w *Writer) Sync() error {
+	if w.closed.Load() {
+		return nil
+	}
+	ack := make(chan struct{})
+	select {
+	case w.syncs <- ack:
+		<-ack
+	case <-w.done:
+	}
+	return nil
+}
+
+// Close 发送剩余的日志并停止后台goroutine，磁盘队列中的批次留到下次启动时重放
+func (w *Writer) Close() error {
+	w.closeOnce.Do(func() {
+		w.closed.Store(true)
+		close(w.stop)
+		<-w.done
+	})
+	return nil
+}
+
+// Stats 返回发送统计
+func (w *Writer) Stats() Stats {
+	s := Stats{
+		Sent:    w.sent.Load(),
+		Dropped: w.dropped.Load(),
+		Spilled: w.spilled.Load(),
+		Evicted: w.evicted.Load(),
+	}
+	if w.queue != nil {
+		s.Queued = w.queue.Len()
+	}
+	return s
+}
+
+// run 后台goroutine，合并批次、发送和重放磁盘队列
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry := <-w.entries:
+			w.add(entry)
+		case <-ticker.C:
+			w.flush()
+		case ack := <-w.syncs:
+			w.drain()
+			w.flush()
+			close(ack)
+		case <-w.replay:
+			w.replayOne()
+		case <-w.stop:
+			w.drain()
+			w.flush()
+			return
+		}
+	}
+}
+
+// add 加入当前批次，达到条数或大小上限时发送
+func (w *Writer) add(entry []byte) {
+	if w.batchSize > 0 && w.batchSize+len(entry) > w.opts.BatchBytes {
+		w.flush()
+	}
+	w.batch = append(w.batch, entry)
+	w.batchSize += len(entry)
+	if len(w.batch) >= w.opts.BatchSize {
+		w.flush()
+	}
+}
+
+// drain 取出内存缓存中的全部日志
+func (w *Writer) drain() {
+	for {
+		select {
+		case entry := <-w.entries:
+			w.add(entry)
+		default:
+			return
+		}
+	}
+}
+
+// flush 发送当前批次
+// 磁盘队列中还有批次时直接写入队列，保证重放后的顺序与写入顺序一致
+func (w *Writer) flush() {
+	if len(w.batch) == 0 {
+		return
+	}
+	data := bytes.Join(w.batch, nil)
+	n := len(w.batch)
+	w.batch = w.batch[:0]
+	w.batchSize = 0
+
+	if w.queue != nil && w.queue.Len() > 0 {
+		w.spill(data, n)
+		return
+	}
+
+	err := w.sendWithRetry(data)
+	switch {
+	case err == nil:
+		w.sent.Add(uint64(n))
+	case isRetryable(err):
+		w.opts.OnError(fmt.Errorf("ship: send %d entries: %w", n, err))
+		w.spill(data, n)
+	default:
+		w.opts.OnError(fmt.Errorf("ship: drop %d entries: %w", n, err))
+		w.dropped.Add(uint64(n))
+	}
+}
+
+// spill 把批次写入磁盘队列，并安排重放
+func (w *Writer) spill(data []byte, n int) {
+	if w.queue == nil {
+		w.dropped.Add(uint64(n))
+		return
+	}
+	evicted, err := w.queue.push(data)
+	if err != nil {
+		w.opts.OnError(err)
+		w.dropped.Add(uint64(n))
+		return
+	}
+	w.spilled.Add(1)
+	w.evicted.Add(uint64(evicted))
+	if w.replay == nil {
+		w.replay = time.After(w.jitter(w.backoff))
+	}
+}
+
+// replayOne 重放磁盘队列中最早的批次，失败时退避后再试
+func (w *Writer) replayOne() {
+	w.replay = nil
+
+	name, data, err := w.queue.peek()
+	if err != nil {
+		w.opts.OnError(fmt.Errorf("ship: read queue: %w", err))
+		w.queue.remove(name)
+	} else if name == "" {
+		return
+	} else if err := w.send(data); err != nil && isRetryable(err) {
+		w.backoff = min(w.backoff*2, w.opts.MaxBackoff)
+		w.replay = time.After(w.jitter(w.backoff))
+		return
+	} else {
+		if err != nil {
+			w.opts.OnError(fmt.Errorf("ship: drop queued batch: %w", err))
+			w.dropped.Add(uint64(bytes.Count(data, []byte{'\n'})))
+		} else {
+			w.sent.Add(uint64(bytes.Count(data, []byte{'\n'})))
+		}
+		w.queue.remove(name)
+	}
+
+	w.backoff = w.opts.MinBackoff
+	if w.queue.Len() > 0 {
+		w.replay = time.After(0)
+	}
+}
+
+// sendWithRetry 发送失败时按指数退避重试MaxRetries次
+func (w *Writer) sendWithRetry(data []byte) error {
+	backoff := w.opts.MinBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = w.send(data); err == nil || !isRetryable(err) || attempt >= w.opts.MaxRetries {
+			return err
+		}
+		select {
+		case <-time.After(w.jitter(backoff)):
+		case <-w.stop:
+			// 关闭时不再等待，剩余的批次写入磁盘队列
+			return err
+		}
+		backoff = min(backoff*2, w.opts.MaxBackoff)
+	}
+}
+
+// jitter 在[d/2, d)之间随机，避免多个实例同时重试
+func (w *Writer) jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// statusError 收集端返回的非2xx状态码
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.code)
+}
+
+// isRetryable 网络错误、5xx和429可以重试，其他4xx说明请求本身有问题，重试也不会成功
+func isRetryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 || se.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// send 发送一个NDJSON批次
+func (w *Writer) send(data []byte) error {
+	body := data
+	if w.opts.Gzip {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(data)
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		body = buf.Bytes()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if w.opts.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range w.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{code: resp.StatusCode}
+	}
+	return nil
+}
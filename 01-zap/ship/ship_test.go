@@ -0,0 +1,193 @@
+package ship
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// collector 模拟收集端，down为true时返回503
+type collector struct {
+	down   atomic.Bool
+	status atomic.Int32
+
+	mu       sync.Mutex
+	lines    []string
+	requests int
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if c.down.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if code := c.status.Load(); code != 0 {
+		w.WriteHeader(int(code))
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/x-ndjson" {
+		http.Error(w, "bad content type", http.StatusBadRequest)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+	sc := bufio.NewScanner(body)
+	for sc.Scan() {
+		c.lines = append(c.lines, sc.Text())
+	}
+}
+
+func (c *collector) received() ([]string, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.lines...), c.requests
+}
+
+func newTestWriter(t *testing.T, url string, opts Options) *Writer {
+	t.Helper()
+	opts.Endpoint = url
+	opts.MinBackoff = 5 * time.Millisecond
+	opts.MaxBackoff = 20 * time.Millisecond
+	opts.OnError = func(error) {}
+	w, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func writeEntries(t *testing.T, w *Writer, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		if _, err := fmt.Fprintf(w, `{"level":"info","msg":"entry","n":%d}`+"\n", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertOrdered(t *testing.T, lines []string, n int) {
+	t.Helper()
+	if len(lines) != n {
+		t.Fatalf("received %d entries, want %d", len(lines), n)
+	}
+	for i, line := range lines {
+		want := fmt.Sprintf(`{"level":"info","msg":"entry","n":%d}`, i)
+		if line != want {
+			t.Fatalf("entry %d = %s, want %s", i, line, want)
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWriterBatchesGzip(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	w := newTestWriter(t, srv.URL, Options{Gzip: true, BatchSize: 4, FlushInterval: time.Hour})
+	writeEntries(t, w, 0, 10)
+	w.Sync()
+
+	lines, requests := c.received()
+	assertOrdered(t, lines, 10)
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3 (4+4+2)", requests)
+	}
+	if s := w.Stats(); s.Sent != 10 || s.Dropped != 0 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestWriterSpillAndReplay(t *testing.T) {
+	c := &collector{}
+	c.down.Store(true)
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	w := newTestWriter(t, srv.URL, Options{MaxRetries: 1, QueueDir: t.TempDir(), FlushInterval: time.Hour})
+	writeEntries(t, w, 0, 5)
+	w.Sync()
+	writeEntries(t, w, 5, 8)
+	w.Sync()
+
+	if s := w.Stats(); s.Spilled != 2 || s.Queued == 0 {
+		t.Fatalf("stats = %+v, want 2 spilled batches", s)
+	}
+
+	// 收集端恢复后按顺序重放
+	c.down.Store(false)
+	waitFor(t, func() bool { return w.Stats().Queued == 0 })
+	writeEntries(t, w, 8, 10)
+	w.Sync()
+
+	lines, _ := c.received()
+	assertOrdered(t, lines, 10)
+}
+
+func TestWriterReplayAfterRestart(t *testing.T) {
+	c := &collector{}
+	c.down.Store(true)
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	dir := t.TempDir()
+
+	w := newTestWriter(t, srv.URL, Options{MaxRetries: 1, QueueDir: dir})
+	writeEntries(t, w, 0, 3)
+	w.Close()
+	if _, err := w.Write([]byte("{}\n")); err != ErrClosed {
+		t.Errorf("Write after Close = %v, want ErrClosed", err)
+	}
+
+	c.down.Store(false)
+	w2 := newTestWriter(t, srv.URL, Options{QueueDir: dir})
+	waitFor(t, func() bool { return w2.Stats().Queued == 0 })
+
+	lines, _ := c.received()
+	assertOrdered(t, lines, 3)
+}
+
+func TestWriterDropsRejectedBatch(t *testing.T) {
+	c := &collector{}
+	c.status.Store(http.StatusBadRequest)
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	w := newTestWriter(t, srv.URL, Options{QueueDir: t.TempDir()})
+	writeEntries(t, w, 0, 3)
+	w.Sync()
+
+	// 4xx 重试也不会成功，不写入磁盘队列
+	if s := w.Stats(); s.Dropped != 3 || s.Spilled != 0 {
+		t.Errorf("stats = %+v, want 3 dropped", s)
+	}
+}
@@ -0,0 +1,257 @@
+// Package accesslog 访问日志中间件，支持Common Log Format、Combined Log Format和JSON
+//
+//	stack.Use("accesslog", accesslog.Middleware(accesslog.Options{Format: accesslog.FormatCombined}))
+//
+// 输出：
+//
+//	127.0.0.1 - - [14/Jan/2025:20:23:25 +0800] "GET /hello HTTP/1.1" 200 12 "-" "curl/8.5.0"
+package accesslog
+
+import (
+	"02-middleware/middleware"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// 日志格式
+const (
+	FormatCommon   = "common"   // Common Log Format
+	FormatCombined = "combined" // Combined Log Format，在CLF后面添加Referer和User-Agent
+	FormatJSON     = "json"     // 每行一个JSON对象
+)
+
+// clfTime CLF中的时间格式
+const clfTime = "02/Jan/2006:15:04:05 -0700"
+
+// Logger 输出一行访问日志，*log.Logger 和 *logrus.Logger 都满足
+type Logger interface {
+	Print(v ...any)
+}
+
+// Options 访问日志配置
+type Options struct {
+	// Format common/combined/json，默认combined
+	Format string
+	// Logger 默认输出到标准输出，不带前缀和时间
+	Logger Logger
+}
+
+// Entry 一次请求的访问记录
+type Entry struct {
+	Time       time.Time     // 收到请求的时间
+	RemoteAddr string        // 客户端地址，不含端口
+	User       string        // Basic认证的用户名
+	Method     string        // 请求方法
+	URI        string        // 请求的URI，含查询参数
+	Proto      string        // 协议版本，如 HTTP/1.1
+	Status     int           // 响应状态码
+	Bytes      int64         // 响应体字节数
+	Duration   time.Duration // 处理耗时
+	TTFB       time.Duration // 首字节时间
+	Referer    string
+	UserAgent  string
+	RequestID  string // 请求头或响应头中的X-Request-ID
+}
+
+// Middleware 访问日志中间件，放在最外层才能记录其它中间件写入的响应（如认证失败的401）
+// Format不支持时panic
+func Middleware(opts Options) middleware.Middleware {
+	format, err := ParseFormat(opts.Format)
+	if err != nil {
+		panic(err)
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.New(os.Stdout, "", 0)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := middleware.WrapWriter(w)
+			defer func() {
+				entry := newEntry(r, rw, start)
+				p := recover()
+				if entry.Status == 0 {
+					// 没有写入任何内容时net/http返回200；panic时会断开连接，按500记录
+					entry.Status = http.StatusOK
+					if p != nil {
+						entry.Status = http.StatusInternalServerError
+					}
+				}
+				logger.Print(string(format(entry)))
+				if p != nil {
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// Formatter 把访问记录格式化为一行日志，不含换行符
+type Formatter func(e *Entry) []byte
+
+// ParseFormat 根据名称返回Formatter，空字符串为combined
+func ParseFormat(name string) (Formatter, error) {
+	switch name {
+	case "", FormatCombined:
+		return Combined, nil
+	case FormatCommon:
+		return Common, nil
+	case FormatJSON:
+		return JSON, nil
+	default:
+		return nil, fmt.Errorf("accesslog: unknown format %q", name)
+	}
+}
+
+// newEntry 收集请求和响应信息
+func newEntry(r *http.Request, rw middleware.ResponseWriter, start time.Time) *Entry {
+	e := &Entry{
+		Time:       start,
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		URI:        r.RequestURI,
+		Proto:      r.Proto,
+		Status:     rw.Status(),
+		Bytes:      rw.BytesWritten(),
+		Duration:   time.Since(start),
+		TTFB:       rw.TTFB(),
+		Referer:    r.Referer(),
+		UserAgent:  r.UserAgent(),
+		RequestID:  r.Header.Get("X-Request-ID"),
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		e.RemoteAddr = host
+	}
+	if user, _, ok := r.BasicAuth(); ok {
+		e.User = user
+	}
+	if e.URI == "" {
+		e.URI = r.URL.RequestURI()
+	}
+	if e.RequestID == "" {
+		e.RequestID = rw.Header().Get("X-Request-ID")
+	}
+	return e
+}
+
+// Common 输出Common Log Format
+//
+//	host ident authuser [date] "request" status bytes
+func Common(e *Entry) []byte {
+	var buf bytes.Buffer
+	writeCommon(&buf, e)
+	return buf.Bytes()
+}
+
+// Combined 输出Combined Log Format，在CLF后面添加 "referer" "user-agent"
+func Combined(e *Entry) []byte {
+	var buf bytes.Buffer
+	writeCommon(&buf, e)
+	buf.WriteByte(' ')
+	writeQuoted(&buf, e.Referer)
+	buf.WriteByte(' ')
+	writeQuoted(&buf, e.UserAgent)
+	return buf.Bytes()
+}
+
+func writeCommon(buf *bytes.Buffer, e *Entry) {
+	buf.WriteString(orDash(e.RemoteAddr))
+	buf.WriteString(" - ")
+	buf.WriteString(orDash(escape(e.User)))
+	buf.WriteString(" [")
+	buf.WriteString(e.Time.Format(clfTime))
+	buf.WriteString("] ")
+	writeQuoted(buf, e.Method+" "+e.URI+" "+e.Proto)
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(e.Status))
+	buf.WriteByte(' ')
+	// CLF中没有响应体时使用 -
+	if e.Bytes == 0 {
+		buf.WriteByte('-')
+	} else {
+		buf.WriteString(strconv.FormatInt(e.Bytes, 10))
+	}
+}
+
+// writeQuoted 写入带引号的字段，空值为 "-"
+func writeQuoted(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	buf.WriteString(orDash(escape(s)))
+	buf.WriteByte('"')
+}
+
+// escape 与Apache一样转义引号、反斜杠和控制字符，避免伪造日志行
+func escape(s string) string {
+	var buf []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c < 0x20 || c == 0x7f:
+			buf = append(buf, fmt.Sprintf(`\x%02x`, c)...)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return string(buf)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// jsonEntry JSON格式的字段，耗时使用毫秒
+type jsonEntry struct {
+	Time       string  `json:"time"`
+	RemoteAddr string  `json:"remote_addr"`
+	User       string  `json:"user,omitempty"`
+	Method     string  `json:"method"`
+	URI        string  `json:"uri"`
+	Proto      string  `json:"proto"`
+	Status     int     `json:"status"`
+	Bytes      int64   `json:"bytes"`
+	DurationMs float64 `json:"duration_ms"`
+	TTFBMs     float64 `json:"ttfb_ms"`
+	Referer    string  `json:"referer,omitempty"`
+	UserAgent  string  `json:"user_agent,omitempty"`
+	RequestID  string  `json:"request_id,omitempty"`
+}
+
+// JSON 输出一个JSON对象
+func JSON(e *Entry) []byte {
+	b, _ := json.Marshal(jsonEntry{
+		Time:       e.Time.Format("2006-01-02T15:04:05.000Z07:00"),
+		RemoteAddr: e.RemoteAddr,
+		User:       e.User,
+		Method:     e.Method,
+		URI:        e.URI,
+		Proto:      e.Proto,
+		Status:     e.Status,
+		Bytes:      e.Bytes,
+		DurationMs: milliseconds(e.Duration),
+		TTFBMs:     milliseconds(e.TTFB),
+		Referer:    e.Referer,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+	})
+	return b
+}
+
+// milliseconds 保留3位小数
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,112 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lines 收集输出的日志
+type lines []string
+
+func (l *lines) Print(v ...any) { *l = append(*l, fmt.Sprint(v...)) }
+
+func testEntry() *Entry {
+	return &Entry{
+		Time:       time.Date(2025, 1, 14, 20, 23, 25, 0, time.FixedZone("CST", 8*3600)),
+		RemoteAddr: "127.0.0.1",
+		Method:     "GET",
+		URI:        "/hello?name=a",
+		Proto:      "HTTP/1.1",
+		Status:     200,
+		Bytes:      13,
+		Duration:   1500 * time.Microsecond,
+		TTFB:       250 * time.Microsecond,
+		Referer:    "",
+		UserAgent:  `curl/8.5.0 "x"`,
+		RequestID:  "req-1",
+	}
+}
+
+func TestFormats(t *testing.T) {
+	e := testEntry()
+	tests := []struct {
+		name string
+		f    Formatter
+		want string
+	}{
+		{"common", Common, `127.0.0.1 - - [14/Jan/2025:20:23:25 +0800] "GET /hello?name=a HTTP/1.1" 200 13`},
+		{"combined", Combined, `127.0.0.1 - - [14/Jan/2025:20:23:25 +0800] "GET /hello?name=a HTTP/1.1" 200 13 "-" "curl/8.5.0 \"x\""`},
+		{"json", JSON, `{"time":"2025-01-14T20:23:25.000+08:00","remote_addr":"127.0.0.1","method":"GET","uri":"/hello?name=a","proto":"HTTP/1.1","status":200,"bytes":13,"duration_ms":1.5,"ttfb_ms":0.25,"user_agent":"curl/8.5.0 \"x\"","request_id":"req-1"}`},
+	}
+	for _, tt := range tests {
+		if got := string(tt.f(e)); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+
+	// 控制字符被转义，不能伪造日志行
+	e.URI = "/a\nfake"
+	e.Bytes = 0
+	if got := string(Common(e)); !strings.Contains(got, `"GET /a\x0afake HTTP/1.1" 200 -`) {
+		t.Errorf("escape: %s", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var out lines
+	h := Middleware(Options{Format: FormatJSON, Logger: &out})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unauthorized":
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		case "/panic":
+			panic("boom")
+		}
+	}))
+
+	serve := func(path string) {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.SetBasicAuth("alice", "secret")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	serve("/empty")
+	serve("/unauthorized")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic was swallowed")
+			}
+		}()
+		serve("/panic")
+	}()
+
+	want := []struct {
+		status int
+		bytes  int64
+	}{{200, 0}, {401, 13}, {500, 0}}
+	if len(out) != len(want) {
+		t.Fatalf("got %d lines: %v", len(out), out)
+	}
+	for i, line := range out {
+		var e jsonEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Status != want[i].status || e.Bytes != want[i].bytes || e.User != "alice" || e.RemoteAddr != "192.0.2.1" {
+			t.Errorf("line %d: %s", i, line)
+		}
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for unknown format")
+		}
+	}()
+	Middleware(Options{Format: "xml"})
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// APIKeys 静态API Key，只保存SHA-256哈希，配置文件泄露也拿不到原始的Key
+type APIKeys struct {
+	keys map[[sha256.Size]byte]string
+}
+
+// HashAPIKey 计算API Key的哈希，用于生成配置
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKeys 创建API Key校验器，hashes为 HashAPIKey 的结果到调用方名称的映射
+func NewAPIKeys(hashes map[string]string) (*APIKeys, error) {
+	keys := make(map[[sha256.Size]byte]string, len(hashes))
+	for h, subject := range hashes {
+		b, err := hex.DecodeString(h)
+		if err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("auth: invalid api key hash for %q", subject)
+		}
+		keys[[sha256.Size]byte(b)] = subject
+	}
+	return &APIKeys{keys: keys}, nil
+}
+
+// Validate 实现TokenValidator，Claims.Subject为调用方名称
+// 先计算哈希再查找，查找耗时与原始Key无关
+func (a *APIKeys) Validate(_ context.Context, token string) (*Claims, error) {
+	subject, ok := a.keys[sha256.Sum256([]byte(token))]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown api key", ErrInvalidToken)
+	}
+	return &Claims{Subject: subject}, nil
+}
@@ -0,0 +1,127 @@
+// Package auth 可替换的令牌校验，为AuthMiddleware提供JWT、API Key和JWKS的实现
+//
+//	validator := auth.NewHS256([]byte(secret), auth.JWTOptions{Issuer: "demo", Audience: "api"})
+//	stack.Use("auth", auth.Middleware(validator))
+//
+// 校验通过后Claims保存在请求的context中，通过 auth.FromContext(r.Context()) 获取。
+package auth
+
+import (
+	"02-middleware/middleware"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingToken     = errors.New("auth: missing bearer token")
+	ErrInvalidToken     = errors.New("auth: invalid token")
+	ErrInvalidSignature = errors.New("auth: invalid signature")
+	ErrUnknownKey       = errors.New("auth: unknown key")
+	ErrExpired          = errors.New("auth: token expired")
+	ErrNotYetValid      = errors.New("auth: token not yet valid")
+	ErrInvalidIssuer    = errors.New("auth: invalid issuer")
+	ErrInvalidAudience  = errors.New("auth: invalid audience")
+)
+
+// TokenValidator 校验令牌并返回其中的声明
+type TokenValidator interface {
+	Validate(ctx context.Context, token string) (*Claims, error)
+}
+
+// ValidatorFunc 函数形式的TokenValidator
+type ValidatorFunc func(ctx context.Context, token string) (*Claims, error)
+
+// Validate 实现TokenValidator
+func (f ValidatorFunc) Validate(ctx context.Context, token string) (*Claims, error) {
+	return f(ctx, token)
+}
+
+// Claims 校验通过的令牌声明
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	// Raw 全部声明，API Key没有原始声明
+	Raw map[string]any
+}
+
+type claimsKey struct{}
+
+// WithClaims 把Claims保存到context中
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext 获取AuthMiddleware保存的Claims
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// BearerToken 从Authorization头中获取Bearer令牌，scheme不区分大小写
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Middleware 认证中间件，校验Bearer令牌并把Claims保存到请求的context中
+// 失败时返回401，通过WWW-Authenticate说明原因，不会把校验错误的细节返回给客户端
+func Middleware(v TokenValidator) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := BearerToken(r)
+			if !ok {
+				unauthorized(w, ErrMissingToken)
+				return
+			}
+			claims, err := v.Validate(r.Context(), token)
+			if err != nil {
+				unauthorized(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// unauthorized 按RFC 6750返回401
+func unauthorized(w http.ResponseWriter, err error) {
+	challenge := `Bearer`
+	if !errors.Is(err, ErrMissingToken) {
+		challenge = `Bearer error="invalid_token"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// Any 依次尝试多个TokenValidator，任意一个通过即可，如同时支持JWT和API Key
+// 全部失败时返回第一个错误
+func Any(validators ...TokenValidator) TokenValidator {
+	return ValidatorFunc(func(ctx context.Context, token string) (*Claims, error) {
+		var first error
+		for _, v := range validators {
+			claims, err := v.Validate(ctx, token)
+			if err == nil {
+				return claims, nil
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		if first == nil {
+			first = fmt.Errorf("%w: no validator", ErrInvalidToken)
+		}
+		return nil, first
+	})
+}
@@ -0,0 +1,366 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 1, 17, 10, 0, 0, 0, time.UTC)
+
+func clock() time.Time { return now }
+
+// sign 生成测试用的JWT
+func sign(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	h := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		h["kid"] = kid
+	}
+	hb, _ := json.Marshal(h)
+	cb, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(cb)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch alg {
+	case HS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case RS256:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case ES256:
+		r, s, err := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub": "user-1",
+		"iss": "demo",
+		"aud": []string{"api", "admin"},
+		"exp": now.Add(time.Minute).Unix(),
+		"nbf": now.Add(-time.Minute).Unix(),
+	}
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secret := []byte("secret")
+	opts := JWTOptions{Issuer: "demo", Audience: "api", Clock: clock}
+
+	tests := []struct {
+		name      string
+		validator *JWTValidator
+		token     string
+	}{
+		{"HS256", NewHS256(secret, opts), sign(t, HS256, "", secret, validClaims())},
+		{"RS256", NewRS256(&rsaKey.PublicKey, opts), sign(t, RS256, "", rsaKey, validClaims())},
+		{"ES256", NewES256(&ecKey.PublicKey, opts), sign(t, ES256, "", ecKey, validClaims())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.validator.Validate(context.Background(), tt.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != "user-1" || claims.Issuer != "demo" || len(claims.Audience) != 2 {
+				t.Fatalf("claims = %+v", claims)
+			}
+			// 其它密钥签名的令牌不能通过
+			other := sign(t, HS256, "", []byte("other"), validClaims())
+			if _, err := tt.validator.Validate(context.Background(), other); err == nil {
+				t.Fatal("expected error for token signed with another key")
+			}
+		})
+	}
+
+	// RS256的公钥不能作为HS256的密钥使用
+	if _, err := NewRS256(&rsaKey.PublicKey, opts).Validate(context.Background(), tests[0].token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("alg confusion err = %v", err)
+	}
+}
+
+func TestJWTClaims(t *testing.T) {
+	secret := []byte("secret")
+	v := NewHS256(secret, JWTOptions{Issuer: "demo", Audience: "api", Leeway: 30 * time.Second, Clock: clock})
+
+	tests := []struct {
+		name   string
+		modify func(map[string]any)
+		want   error
+	}{
+		{"valid", func(map[string]any) {}, nil},
+		{"expired", func(c map[string]any) { c["exp"] = now.Add(-time.Minute).Unix() }, ErrExpired},
+		{"expired within leeway", func(c map[string]any) { c["exp"] = now.Add(-10 * time.Second).Unix() }, nil},
+		{"not yet valid", func(c map[string]any) { c["nbf"] = now.Add(time.Minute).Unix() }, ErrNotYetValid},
+		{"nbf within leeway", func(c map[string]any) { c["nbf"] = now.Add(10 * time.Second).Unix() }, nil},
+		{"missing exp", func(c map[string]any) { delete(c, "exp") }, ErrInvalidToken},
+		{"wrong issuer", func(c map[string]any) { c["iss"] = "other" }, ErrInvalidIssuer},
+		{"wrong audience", func(c map[string]any) { c["aud"] = "web" }, ErrInvalidAudience},
+		{"string audience", func(c map[string]any) { c["aud"] = "api" }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validClaims()
+			tt.modify(c)
+			_, err := v.Validate(context.Background(), sign(t, HS256, "", secret, c))
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := v.Validate(context.Background(), "not-a-jwt"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("malformed err = %v", err)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys, err := NewAPIKeys(map[string]string{HashAPIKey("valid-token"): "demo-client"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := keys.Validate(context.Background(), "valid-token")
+	if err != nil || claims.Subject != "demo-client" {
+		t.Fatalf("Validate() = %+v, %v", claims, err)
+	}
+	if _, err := keys.Validate(context.Background(), "other"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := NewAPIKeys(map[string]string{"xyz": "bad"}); err == nil {
+		t.Fatal("expected error for invalid hash")
+	}
+}
+
+// jwksJSON 生成包含指定RSA公钥的JWKS
+func jwksJSON(t *testing.T, keys map[string]*rsa.PrivateKey) []byte {
+	t.Helper()
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, k := range keys {
+		set.Keys = append(set.Keys, map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": RS256,
+			"n":   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString([]byte{1, 0, 1}),
+		})
+	}
+	b, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestJWKSURLRotation(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	var body atomic.Value
+	body.Store(jwksJSON(t, map[string]*rsa.PrivateKey{"k1": key1}))
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(body.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	current := now
+	jwks, err := NewJWKS(context.Background(), JWKSOptions{
+		URL:                srv.URL,
+		MinRefreshInterval: time.Minute,
+		Clock:              func() time.Time { return current },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewJWT(jwks.Key, JWTOptions{Clock: func() time.Time { return current }})
+	claims := validClaims()
+	claims["exp"] = now.Add(time.Hour).Unix()
+
+	if _, err := v.Validate(context.Background(), sign(t, RS256, "k1", key1, claims)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 密钥轮换，未知的kid触发刷新
+	body.Store(jwksJSON(t, map[string]*rsa.PrivateKey{"k1": key1, "k2": key2}))
+	current = current.Add(2 * time.Minute)
+	if _, err := v.Validate(context.Background(), sign(t, RS256, "k2", key2, claims)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 间隔内的未知kid不会再次请求
+	before := fetches.Load()
+	if _, err := v.Validate(context.Background(), sign(t, RS256, "k3", key2, claims)); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("err = %v", err)
+	}
+	if fetches.Load() != before {
+		t.Fatalf("fetches = %d, want %d", fetches.Load(), before)
+	}
+}
+
+func TestJWKSSlowRefresh(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	body := jwksJSON(t, map[string]*rsa.PrivateKey{"k1": key1})
+
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 首次加载之后的请求一直等到测试结束
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	var current atomic.Int64
+	current.Store(now.UnixNano())
+	clock := func() time.Time { return time.Unix(0, current.Load()) }
+	jwks, err := NewJWKS(context.Background(), JWKSOptions{URL: srv.URL, RefreshInterval: time.Minute, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewJWT(jwks.Key, JWTOptions{Clock: clock})
+	claims := validClaims()
+	claims["exp"] = now.Add(time.Hour).Unix()
+	token := sign(t, RS256, "k1", key1, claims)
+
+	// 缓存过期，后台加载被阻塞，校验仍使用缓存的密钥
+	current.Store(now.Add(2 * time.Minute).UnixNano())
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 3; i++ {
+			if _, err := v.Validate(context.Background(), token); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("validation blocked by a slow jwks refresh")
+	}
+	// 只开始了一次后台加载
+	deadline := time.Now().Add(2 * time.Second)
+	for fetches.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("fetches = %d, want 2", n)
+	}
+
+	// 未知kid等待正在进行的加载，请求取消时返回
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := v.Validate(ctx, sign(t, RS256, "k2", key1, claims)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestJWKSFile(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwksJSON(t, map[string]*rsa.PrivateKey{"k1": key1}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	current := now
+	jwks, err := NewJWKS(context.Background(), JWKSOptions{File: path, Clock: func() time.Time { return current }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewJWT(jwks.Key, JWTOptions{Clock: func() time.Time { return current }})
+	claims := validClaims()
+	claims["exp"] = now.Add(time.Hour).Unix()
+
+	if err := os.WriteFile(path, jwksJSON(t, map[string]*rsa.PrivateKey{"k2": key2}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	current = current.Add(2 * time.Minute)
+	if _, err := v.Validate(context.Background(), sign(t, RS256, "k2", key2, claims)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Validate(context.Background(), sign(t, RS256, "k1", key1, claims)); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("removed key err = %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	keys, _ := NewAPIKeys(map[string]string{HashAPIKey("valid-token"): "demo-client"})
+	h := Middleware(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := FromContext(r.Context())
+		if !ok {
+			t.Fatal("claims not in context")
+		}
+		w.Write([]byte(claims.Subject))
+	}))
+
+	tests := []struct {
+		header    string
+		code      int
+		challenge string
+	}{
+		{"Bearer valid-token", http.StatusOK, ""},
+		{"bearer valid-token", http.StatusOK, ""},
+		{"valid-token", http.StatusUnauthorized, "Bearer"},
+		{"", http.StatusUnauthorized, "Bearer"},
+		{"Bearer wrong", http.StatusUnauthorized, `Bearer error="invalid_token"`},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code || w.Header().Get("WWW-Authenticate") != tt.challenge {
+			t.Errorf("%q: code = %d, challenge = %q", tt.header, w.Code, w.Header().Get("WWW-Authenticate"))
+		}
+		if tt.code == http.StatusOK && w.Body.String() != "demo-client" {
+			t.Errorf("%q: body = %q", tt.header, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// JWKSOptions JWKS密钥集的来源和刷新策略，URL和File二选一
+type JWKSOptions struct {
+	URL  string // 如 https://issuer/.well-known/jwks.json
+	File string // 本地文件，修改时间变化后重新加载
+	// RefreshInterval 缓存的有效期，过期后使用时重新加载，默认1h
+	RefreshInterval time.Duration
+	// MinRefreshInterval 遇到未知kid时强制刷新的最小间隔，避免伪造的kid打满JWKS接口，默认1m
+	MinRefreshInterval time.Duration
+	// Client 获取URL使用的客户端，默认10s超时
+	Client *http.Client
+	// Clock 获取当前时间，默认time.Now，用于测试
+	Clock func() time.Time
+}
+
+// jwk JWKS中的一个密钥
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwkKey 解析后的公钥
+type jwkKey struct {
+	alg string
+	key any
+}
+
+// JWKS 缓存的JWKS密钥集，密钥轮换时按kid自动刷新
+// 只支持RSA和P-256公钥，对称密钥不应通过JWKS发布
+// 加载在锁外进行，同一时间只有一次加载，加载期间继续使用缓存的密钥
+type JWKS struct {
+	opts JWKSOptions
+
+	mu       sync.Mutex
+	keys     map[string]jwkKey
+	fetched  time.Time    // 上次成功加载的时间
+	tried    time.Time    // 上次开始加载的时间
+	modTime  time.Time    // File的修改时间
+	inflight *refreshCall // 正在进行的加载
+}
+
+// refreshCall 一次加载，等待的调用方共享结果
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// NewJWKS 创建并加载JWKS密钥集，首次加载失败返回错误
+func NewJWKS(ctx context.Context, opts JWKSOptions) (*JWKS, error) {
+	if (opts.URL == "") == (opts.File == "") {
+		return nil, errors.New("auth: exactly one of jwks url and file is required")
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = time.Hour
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = time.Minute
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	k := &JWKS{opts: opts}
+	if err := k.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Key 实现KeyFunc，用于 NewJWT(jwks.Key, opts)
+// 缓存过期时在后台重新加载，本次仍使用缓存的密钥；
+// 找不到kid时按MinRefreshInterval限制强制加载一次并等待结果
+func (k *JWKS) Key(ctx context.Context, alg, kid string) (any, error) {
+	k.mu.Lock()
+	now := k.opts.Clock()
+	key, ok := k.lookup(kid)
+	var call *refreshCall
+	switch {
+	case !ok:
+		// 已经有加载在进行时等待它，否则受MinRefreshInterval限制
+		call = k.inflight
+		if call == nil && now.Sub(k.tried) >= k.opts.MinRefreshInterval {
+			call = k.startLocked(context.WithoutCancel(ctx))
+		}
+	case now.Sub(k.fetched) >= k.opts.RefreshInterval && now.Sub(k.tried) >= k.opts.MinRefreshInterval && k.inflight == nil:
+		// 刷新失败时继续使用缓存的密钥
+		k.startLocked(context.Background())
+	}
+	k.mu.Unlock()
+
+	if call != nil {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err == nil {
+			k.mu.Lock()
+			key, ok = k.lookup(kid)
+			k.mu.Unlock()
+		}
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	if key.alg != "" && key.alg != alg {
+		return nil, fmt.Errorf("%w: kid %q is for %s, got %q", ErrInvalidToken, kid, key.alg, alg)
+	}
+	return key.key, nil
+}
+
+// lookup 按kid查找，没有kid时只有一个密钥才能使用，调用方持有mu
+func (k *JWKS) lookup(kid string) (jwkKey, bool) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// Refresh 立即重新加载密钥集并等待结果，失败时保留原来的密钥
+// 已经有加载在进行时等待它完成
+func (k *JWKS) Refresh(ctx context.Context) error {
+	k.mu.Lock()
+	call := k.inflight
+	if call == nil {
+		call = k.startLocked(context.WithoutCancel(ctx))
+	}
+	k.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startLocked 在后台开始一次加载，调用方持有mu
+// 加载不受单个请求取消的影响，超时由Client控制
+func (k *JWKS) startLocked(ctx context.Context) *refreshCall {
+	call := &refreshCall{done: make(chan struct{})}
+	k.inflight = call
+	k.tried = k.opts.Clock()
+	modTime, loaded := k.modTime, k.keys != nil
+
+	go func() {
+		keys, newModTime, err := k.load(ctx, modTime, loaded)
+
+		k.mu.Lock()
+		if err == nil {
+			if keys != nil {
+				k.keys = keys
+				k.modTime = newModTime
+			}
+			k.fetched = k.opts.Clock()
+		}
+		k.inflight = nil
+		k.mu.Unlock()
+
+		call.err = err
+		close(call.done)
+	}()
+	return call
+}
+
+// load 读取并解析密钥集，文件没有变化时返回nil
+func (k *JWKS) load(ctx context.Context, modTime time.Time, loaded bool) (map[string]jwkKey, time.Time, error) {
+	var data []byte
+	if k.opts.File != "" {
+		info, err := os.Stat(k.opts.File)
+		if err != nil {
+			return nil, modTime, fmt.Errorf("auth: load jwks: %w", err)
+		}
+		// 文件没有变化时不需要重新解析
+		if loaded && info.ModTime().Equal(modTime) {
+			return nil, modTime, nil
+		}
+		if data, err = os.ReadFile(k.opts.File); err != nil {
+			return nil, modTime, fmt.Errorf("auth: load jwks: %w", err)
+		}
+		modTime = info.ModTime()
+	} else {
+		var err error
+		if data, err = k.fetch(ctx); err != nil {
+			return nil, modTime, fmt.Errorf("auth: load jwks: %w", err)
+		}
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, modTime, err
+	}
+	return keys, modTime, nil
+}
+
+// fetch 获取URL中的JWKS
+func (k *JWKS) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := k.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parseJWKS 解析JWKS，跳过不支持和非签名用途的密钥
+func parseJWKS(data []byte) (map[string]jwkKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("auth: parse jwks: %w", err)
+	}
+
+	keys := make(map[string]jwkKey, len(set.Keys))
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		key, alg, err := j.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("auth: parse jwks kid %q: %w", j.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		if j.Alg != "" {
+			alg = j.Alg
+		}
+		keys[j.Kid] = jwkKey{alg: alg, key: key}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("auth: jwks has no usable keys")
+	}
+	return keys, nil
+}
+
+// publicKey 转换为公钥，不支持的类型返回nil
+func (j jwk) publicKey() (any, string, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, "", fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, "", fmt.Errorf("e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, "", errors.New("e is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, RS256, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, "", nil
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, "", fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, "", fmt.Errorf("y: %w", err)
+		}
+		// 通过crypto/ecdh校验点在曲线上，避免无效曲线攻击
+		point := make([]byte, 65)
+		point[0] = 4
+		if len(x.Bytes()) > 32 || len(y.Bytes()) > 32 {
+			return nil, "", errors.New("point is not on curve")
+		}
+		x.FillBytes(point[1:33])
+		y.FillBytes(point[33:])
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, "", errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, ES256, nil
+	default:
+		return nil, "", nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// 支持的签名算法
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+// KeyFunc 根据JWT头中的alg和kid返回校验签名的密钥
+// HS256返回[]byte，RS256返回*rsa.PublicKey，ES256返回*ecdsa.PublicKey
+type KeyFunc func(ctx context.Context, alg, kid string) (any, error)
+
+// JWTOptions JWT声明的校验规则
+type JWTOptions struct {
+	Issuer   string        // 不为空时iss必须相同
+	Audience string        // 不为空时aud必须包含该值
+	Leeway   time.Duration // 校验exp、nbf时允许的时钟偏差
+	// AllowMissingExp 为true时允许没有exp的令牌，默认必须有exp
+	AllowMissingExp bool
+	// Clock 获取当前时间，默认time.Now，用于测试
+	Clock func() time.Time
+}
+
+// JWTValidator 校验JWT的签名和声明
+type JWTValidator struct {
+	keys KeyFunc
+	opts JWTOptions
+}
+
+// NewJWT 使用KeyFunc获取密钥，如JWKS.Key
+func NewJWT(keys KeyFunc, opts JWTOptions) *JWTValidator {
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+	return &JWTValidator{keys: keys, opts: opts}
+}
+
+// NewHS256 使用共享密钥校验HS256签名的JWT
+func NewHS256(secret []byte, opts JWTOptions) *JWTValidator {
+	return NewJWT(staticKey(HS256, secret), opts)
+}
+
+// NewRS256 使用RSA公钥校验RS256签名的JWT
+func NewRS256(key *rsa.PublicKey, opts JWTOptions) *JWTValidator {
+	return NewJWT(staticKey(RS256, key), opts)
+}
+
+// NewES256 使用P-256公钥校验ES256签名的JWT
+func NewES256(key *ecdsa.PublicKey, opts JWTOptions) *JWTValidator {
+	return NewJWT(staticKey(ES256, key), opts)
+}
+
+// staticKey 只接受指定算法，避免使用公钥作为HS256密钥的算法混淆攻击
+func staticKey(alg string, key any) KeyFunc {
+	return func(_ context.Context, tokenAlg, _ string) (any, error) {
+		if tokenAlg != alg {
+			return nil, fmt.Errorf("%w: unexpected alg %q", ErrInvalidToken, tokenAlg)
+		}
+		return key, nil
+	}
+}
+
+// header JWT头
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Validate 实现TokenValidator
+func (v *JWTValidator) Validate(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed jwt", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+
+	key, err := v.keys(ctx, h.Alg, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(h.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	claims, err := parseClaims(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.check(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// check 校验iss、aud、exp、nbf
+func (v *JWTValidator) check(c *Claims) error {
+	now := v.opts.Clock()
+	if c.ExpiresAt.IsZero() {
+		if !v.opts.AllowMissingExp {
+			return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+		}
+	} else if !now.Before(c.ExpiresAt.Add(v.opts.Leeway)) {
+		return fmt.Errorf("%w: expired at %s", ErrExpired, c.ExpiresAt.Format(time.RFC3339))
+	}
+	if !c.NotBefore.IsZero() && now.Add(v.opts.Leeway).Before(c.NotBefore) {
+		return fmt.Errorf("%w: valid from %s", ErrNotYetValid, c.NotBefore.Format(time.RFC3339))
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return fmt.Errorf("%w: %q", ErrInvalidIssuer, c.Issuer)
+	}
+	if v.opts.Audience != "" && !contains(c.Audience, v.opts.Audience) {
+		return fmt.Errorf("%w: %v", ErrInvalidAudience, c.Audience)
+	}
+	return nil
+}
+
+// verify 校验签名，密钥类型必须与算法匹配
+func verify(alg string, key any, signingInput string, sig []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%w: %s requires a shared secret", ErrInvalidToken, alg)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return ErrInvalidSignature
+		}
+	case RS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s requires an RSA key", ErrInvalidToken, alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return ErrInvalidSignature
+		}
+	case ES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return fmt.Errorf("%w: %s requires a P-256 key", ErrInvalidToken, alg)
+		}
+		// JWS中的ECDSA签名是定长的 r||s，不是ASN.1编码
+		if len(sig) != 64 {
+			return ErrInvalidSignature
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, alg)
+	}
+	return nil
+}
+
+// parseClaims 解析标准声明，aud可以是字符串或数组
+func parseClaims(raw map[string]any) (*Claims, error) {
+	c := &Claims{Raw: raw}
+	var err error
+	if c.Subject, err = stringClaim(raw, "sub"); err != nil {
+		return nil, err
+	}
+	if c.Issuer, err = stringClaim(raw, "iss"); err != nil {
+		return nil, err
+	}
+	switch aud := raw["aud"].(type) {
+	case nil:
+	case string:
+		c.Audience = []string{aud}
+	case []any:
+		for _, a := range aud {
+			s, ok := a.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: aud must be strings", ErrInvalidToken)
+			}
+			c.Audience = append(c.Audience, s)
+		}
+	default:
+		return nil, fmt.Errorf("%w: invalid aud", ErrInvalidToken)
+	}
+	if c.ExpiresAt, err = timeClaim(raw, "exp"); err != nil {
+		return nil, err
+	}
+	if c.NotBefore, err = timeClaim(raw, "nbf"); err != nil {
+		return nil, err
+	}
+	if c.IssuedAt, err = timeClaim(raw, "iat"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func stringClaim(raw map[string]any, name string) (string, error) {
+	switch v := raw[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("%w: %s must be a string", ErrInvalidToken, name)
+	}
+}
+
+// timeClaim 解析NumericDate，允许小数秒
+func timeClaim(raw map[string]any, name string) (time.Time, error) {
+	v, ok := raw[name]
+	if !ok {
+		return time.Time{}, nil
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: %s must be a number", ErrInvalidToken, name)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s: %v", ErrInvalidToken, name, err)
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), nil
+}
+
+// decodeSegment 解码base64url编码的JSON，数字保留为json.Number
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
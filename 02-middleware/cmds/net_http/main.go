@@ -1,63 +1,118 @@
 package main
 
 import (
+	"02-middleware/accesslog"
+	"02-middleware/auth"
+	"02-middleware/middleware"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 )
 
-// LoggerMiddleware 日志中间件
-func LoggerMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// 请求前记录时间
-		startTime := time.Now()
-		log.Printf("Started %s %s", r.Method, r.URL.Path)
-
-		// 调用下一个处理函数
-		next(w, r)
-
-		// 请求后记录耗时
-		log.Printf("Completed %s %s in %v", r.Method, r.URL.Path, time.Since(startTime))
+// newValidator 根据环境变量创建令牌校验器
+// AUTH_JWT_SECRET 校验HS256签名的JWT，AUTH_JWKS_URL/AUTH_JWKS_FILE 校验RS256/ES256签名的JWT
+// AUTH_API_KEYS 为逗号分隔的 名称:SHA-256哈希，未配置时使用演示用的API Key "valid-token"
+func newValidator() (auth.TokenValidator, error) {
+	opts := auth.JWTOptions{
+		Issuer:   os.Getenv("AUTH_ISSUER"),
+		Audience: os.Getenv("AUTH_AUDIENCE"),
+		Leeway:   30 * time.Second,
 	}
-}
 
-// AuthMiddleware 认证中间件
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// 从header中获取token
-		token := r.Header.Get("Authorization")
-
-		// 这里简单判断token是否为"valid-token"
-		if token != "valid-token" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
+	var validators []auth.TokenValidator
+	if secret := os.Getenv("AUTH_JWT_SECRET"); secret != "" {
+		validators = append(validators, auth.NewHS256([]byte(secret), opts))
+	}
+	if url, file := os.Getenv("AUTH_JWKS_URL"), os.Getenv("AUTH_JWKS_FILE"); url != "" || file != "" {
+		jwks, err := auth.NewJWKS(context.Background(), auth.JWKSOptions{URL: url, File: file})
+		if err != nil {
+			return nil, err
 		}
+		validators = append(validators, auth.NewJWT(jwks.Key, opts))
+	}
 
-		// 认证通过，调用下一个处理函数
-		next(w, r)
+	hashes := map[string]string{}
+	if keys := os.Getenv("AUTH_API_KEYS"); keys != "" {
+		for _, item := range strings.Split(keys, ",") {
+			name, hash, ok := strings.Cut(strings.TrimSpace(item), ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid AUTH_API_KEYS item %q, want name:sha256", item)
+			}
+			hashes[hash] = name
+		}
+	} else if len(validators) == 0 {
+		log.Println("AUTH_* not set, accepting demo api key \"valid-token\"")
+		hashes[auth.HashAPIKey("valid-token")] = "demo"
+	}
+	if len(hashes) > 0 {
+		apiKeys, err := auth.NewAPIKeys(hashes)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, apiKeys)
 	}
+	return auth.Any(validators...), nil
 }
 
 // 实际的业务处理函数
 func helloHandler(w http.ResponseWriter, r *http.Request) {
+	// 认证中间件保存的调用方信息
+	if claims, ok := auth.FromContext(r.Context()); ok && claims.Subject != "" {
+		fmt.Fprintf(w, "Hello, %s!", claims.Subject)
+		return
+	}
 	fmt.Fprintf(w, "Hello, World!")
 }
 
-// 组合多个中间件
-func Chain(f http.HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) http.HandlerFunc {
-	for _, m := range middlewares {
-		f = m(f)
-	}
-	return f
+// 健康检查，不需要认证
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "ok")
 }
 
 func main() {
+	validator, err := newValidator()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 访问日志格式 common/combined/json，默认combined
+	format := os.Getenv("ACCESS_LOG_FORMAT")
+	if _, err := accesslog.ParseFormat(format); err != nil {
+		log.Fatal(err)
+	}
+
+	// 公共中间件栈，先Use的先执行，访问日志在最外层才能记录认证失败的请求
+	stack := middleware.NewStack().
+		Use("accesslog", accesslog.Middleware(accesslog.Options{Format: format})).
+		Use("auth", auth.Middleware(validator))
+
 	// 组合中间件和处理函数
-	handler := Chain(helloHandler, LoggerMiddleware, AuthMiddleware)
+	hello := stack.ThenFunc(helloHandler)
 
-	// 注册路由
-	http.HandleFunc("/hello", handler)
+	// 健康检查跳过认证
+	health, err := stack.Handler(http.HandlerFunc(healthHandler), middleware.Skip("auth"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 注册路由，并输出每个路由实际生效的中间件链
+	routes := []struct {
+		pattern string
+		handler http.Handler
+	}{
+		{"/hello", hello},
+		{"/health", health},
+	}
+	for _, route := range routes {
+		http.Handle(route.pattern, route.handler)
+		if chain, ok := middleware.Describe(route.handler); ok {
+			log.Printf("route %s: %s", route.pattern, chain)
+		}
+	}
 
 	// 启动服务器
 	fmt.Println("Server starting on port 8080...")
@@ -1 +1,3 @@
 module 02-middleware
+
+go 1.23.4
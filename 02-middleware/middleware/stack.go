@@ -0,0 +1,254 @@
+// Package middleware 按声明顺序执行的net/http中间件栈
+//
+//	stack := middleware.NewStack().
+//		Use("logger", middleware.Func(LoggerMiddleware)).
+//		Use("auth", middleware.Func(AuthMiddleware))
+//	http.Handle("/hello", stack.ThenFunc(helloHandler)) // logger -> auth -> handler
+//
+// 先Use的中间件在最外层，最先处理请求、最后处理响应。
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Middleware 标准的net/http中间件
+type Middleware func(http.Handler) http.Handler
+
+// Func 把 func(http.HandlerFunc) http.HandlerFunc 形式的中间件转换为Middleware
+func Func(m func(http.HandlerFunc) http.HandlerFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return m(next.ServeHTTP)
+	}
+}
+
+// entry 栈中带名称的中间件
+type entry struct {
+	name string
+	mw   Middleware
+}
+
+// Stack 带名称的中间件列表，按声明顺序执行
+// 修改Stack不会影响已经通过Then创建的Handler
+type Stack struct {
+	entries []entry
+}
+
+// NewStack 创建中间件栈
+func NewStack() *Stack {
+	return &Stack{}
+}
+
+// Use 在末尾添加中间件，名称重复时panic，与http.ServeMux重复注册路由一样属于编程错误
+func (s *Stack) Use(name string, m Middleware) *Stack {
+	if s.index(name) >= 0 {
+		panic(fmt.Sprintf("middleware: duplicate middleware %q", name))
+	}
+	s.entries = append(s.entries, entry{name: name, mw: m})
+	return s
+}
+
+// InsertBefore 在target之前插入中间件，插入的中间件先于target执行
+func (s *Stack) InsertBefore(target, name string, m Middleware) error {
+	return s.insert(target, 0, name, m)
+}
+
+// InsertAfter 在target之后插入中间件，插入的中间件在target之后执行
+func (s *Stack) InsertAfter(target, name string, m Middleware) error {
+	return s.insert(target, 1, name, m)
+}
+
+func (s *Stack) insert(target string, offset int, name string, m Middleware) error {
+	if s.index(name) >= 0 {
+		return fmt.Errorf("middleware: duplicate middleware %q", name)
+	}
+	i := s.index(target)
+	if i < 0 {
+		return fmt.Errorf("middleware: %q not found", target)
+	}
+	i += offset
+	s.entries = append(s.entries, entry{})
+	copy(s.entries[i+1:], s.entries[i:])
+	s.entries[i] = entry{name: name, mw: m}
+	return nil
+}
+
+// Replace 替换同名的中间件，位置不变
+func (s *Stack) Replace(name string, m Middleware) error {
+	i := s.index(name)
+	if i < 0 {
+		return fmt.Errorf("middleware: %q not found", name)
+	}
+	s.entries[i].mw = m
+	return nil
+}
+
+// Remove 删除中间件
+func (s *Stack) Remove(name string) error {
+	i := s.index(name)
+	if i < 0 {
+		return fmt.Errorf("middleware: %q not found", name)
+	}
+	s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	return nil
+}
+
+// Names 按执行顺序返回中间件的名称
+func (s *Stack) Names() []string {
+	names := make([]string, len(s.entries))
+	for i, e := range s.entries {
+		names[i] = e.name
+	}
+	return names
+}
+
+// Clone 复制中间件栈，用于在公共栈的基础上为某个路由调整
+func (s *Stack) Clone() *Stack {
+	return &Stack{entries: append([]entry(nil), s.entries...)}
+}
+
+// Override 对某个路由调整中间件栈
+type Override func(*Stack) error
+
+// Skip 去掉指定的中间件，如健康检查接口不需要认证
+func Skip(names ...string) Override {
+	return func(s *Stack) error {
+		for _, name := range names {
+			if err := s.Remove(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Before 在target之前插入中间件
+func Before(target, name string, m Middleware) Override {
+	return func(s *Stack) error {
+		return s.InsertBefore(target, name, m)
+	}
+}
+
+// After 在target之后插入中间件
+func After(target, name string, m Middleware) Override {
+	return func(s *Stack) error {
+		return s.InsertAfter(target, name, m)
+	}
+}
+
+// Swap 替换同名的中间件，如某个路由使用不同的认证方式
+func Swap(name string, m Middleware) Override {
+	return func(s *Stack) error {
+		return s.Replace(name, m)
+	}
+}
+
+// Append 在末尾添加中间件，最靠近Handler
+func Append(name string, m Middleware) Override {
+	return func(s *Stack) error {
+		if s.index(name) >= 0 {
+			return fmt.Errorf("middleware: duplicate middleware %q", name)
+		}
+		s.entries = append(s.entries, entry{name: name, mw: m})
+		return nil
+	}
+}
+
+// With 返回应用了overrides的副本，原来的栈不变
+func (s *Stack) With(overrides ...Override) (*Stack, error) {
+	clone := s.Clone()
+	for _, o := range overrides {
+		if err := o(clone); err != nil {
+			return nil, err
+		}
+	}
+	return clone, nil
+}
+
+// Then 用中间件包装h，返回的Handler实现fmt.Stringer，输出实际生效的中间件链
+func (s *Stack) Then(h http.Handler) http.Handler {
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+	names := s.Names()
+	wrapped := h
+	// 从最后一个开始包装，第一个中间件在最外层
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		wrapped = s.entries[i].mw(wrapped)
+	}
+	return &chain{handler: wrapped, names: names, final: h}
+}
+
+// ThenFunc 与Then相同，参数为HandlerFunc
+func (s *Stack) ThenFunc(f http.HandlerFunc) http.Handler {
+	return s.Then(f)
+}
+
+// Handler 对路由应用overrides后包装h
+//
+//	health, err := stack.Handler(healthHandler, middleware.Skip("auth"))
+func (s *Stack) Handler(h http.Handler, overrides ...Override) (http.Handler, error) {
+	clone, err := s.With(overrides...)
+	if err != nil {
+		return nil, err
+	}
+	return clone.Then(h), nil
+}
+
+// String 输出中间件链，如 logger -> auth
+func (s *Stack) String() string {
+	return strings.Join(s.Names(), " -> ")
+}
+
+func (s *Stack) index(name string) int {
+	for i, e := range s.entries {
+		if e.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// chain Then返回的Handler，记录中间件名称用于调试输出
+type chain struct {
+	handler http.Handler
+	names   []string
+	final   http.Handler
+}
+
+func (c *chain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.handler.ServeHTTP(w, r)
+}
+
+// String 输出实际生效的中间件链和最终的Handler，如 logger -> auth -> main.helloHandler
+func (c *chain) String() string {
+	return strings.Join(append(append([]string(nil), c.names...), handlerName(c.final)), " -> ")
+}
+
+// Names 按执行顺序返回中间件的名称
+func (c *chain) Names() []string {
+	return append([]string(nil), c.names...)
+}
+
+// handlerName 返回Handler的名称，HandlerFunc返回函数名，其它返回类型名
+func handlerName(h http.Handler) string {
+	if f, ok := h.(http.HandlerFunc); ok {
+		if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+// Describe 返回Then创建的Handler的中间件链，其它Handler返回false
+func Describe(h http.Handler) (string, bool) {
+	c, ok := h.(*chain)
+	if !ok {
+		return "", false
+	}
+	return c.String(), true
+}
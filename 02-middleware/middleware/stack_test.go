@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// record 返回记录执行顺序的中间件
+func record(name string, calls *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name+" start")
+			next.ServeHTTP(w, r)
+			*calls = append(*calls, name+" end")
+		})
+	}
+}
+
+func serve(t *testing.T, h http.Handler, calls *[]string) {
+	t.Helper()
+	*calls = (*calls)[:0]
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestStackOrder(t *testing.T) {
+	var calls []string
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+
+	stack := NewStack().Use("logger", record("logger", &calls)).Use("auth", record("auth", &calls))
+	serve(t, stack.Then(final), &calls)
+
+	want := []string{"logger start", "auth start", "handler", "auth end", "logger end"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestStackInsert(t *testing.T) {
+	var calls []string
+	stack := NewStack().Use("logger", record("logger", &calls)).Use("auth", record("auth", &calls))
+
+	if err := stack.InsertBefore("auth", "cors", record("cors", &calls)); err != nil {
+		t.Fatal(err)
+	}
+	if err := stack.InsertAfter("auth", "recover", record("recover", &calls)); err != nil {
+		t.Fatal(err)
+	}
+	if got := stack.String(); got != "logger -> cors -> auth -> recover" {
+		t.Fatalf("String() = %q", got)
+	}
+
+	if err := stack.InsertAfter("missing", "x", record("x", &calls)); err == nil {
+		t.Fatal("expected error for missing target")
+	}
+	if err := stack.InsertBefore("auth", "logger", record("logger", &calls)); err == nil {
+		t.Fatal("expected error for duplicate name")
+	}
+}
+
+func TestStackOverrides(t *testing.T) {
+	var calls []string
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	stack := NewStack().Use("logger", record("logger", &calls)).Use("auth", record("auth", &calls))
+
+	h, err := stack.Handler(final,
+		Skip("auth"),
+		Before("logger", "trace", record("trace", &calls)),
+		Append("timeout", record("timeout", &calls)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(t, h, &calls)
+	want := []string{"trace start", "logger start", "timeout start", "timeout end", "logger end", "trace end"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+
+	// 原来的栈不受影响
+	if got := stack.String(); got != "logger -> auth" {
+		t.Fatalf("stack changed: %q", got)
+	}
+
+	h, err = stack.Handler(final, Swap("auth", record("apikey", &calls)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(t, h, &calls)
+	want = []string{"logger start", "apikey start", "apikey end", "logger end"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+
+	if _, err := stack.Handler(final, Skip("missing")); err == nil {
+		t.Fatal("expected error for missing middleware")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	stack := NewStack().Use("logger", Func(func(next http.HandlerFunc) http.HandlerFunc { return next }))
+	h := stack.ThenFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	chain, ok := Describe(h)
+	if !ok {
+		t.Fatal("Describe() ok = false")
+	}
+	if !strings.HasPrefix(chain, "logger -> ") || !strings.Contains(chain, "TestDescribe") {
+		t.Fatalf("Describe() = %q", chain)
+	}
+	if _, ok := Describe(http.NotFoundHandler()); ok {
+		t.Fatal("Describe() ok = true for plain handler")
+	}
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseWriter 记录状态码、写入字节数和首字节时间的http.ResponseWriter
+// 原始的ResponseWriter实现了http.Flusher、http.Hijacker、io.ReaderFrom时，包装后同样实现
+type ResponseWriter interface {
+	http.ResponseWriter
+	// Status 响应的状态码，还没有写入时返回0
+	Status() int
+	// BytesWritten 写入的响应体字节数
+	BytesWritten() int64
+	// TTFB 从包装到写出响应头的时间，还没有写入时返回0
+	TTFB() time.Duration
+	// Written 是否已经写出响应头
+	Written() bool
+	// Unwrap 返回原始的ResponseWriter，供http.ResponseController使用
+	Unwrap() http.ResponseWriter
+}
+
+// WrapWriter 包装ResponseWriter，已经包装过的直接返回
+func WrapWriter(w http.ResponseWriter) ResponseWriter {
+	if rw, ok := w.(ResponseWriter); ok {
+		return rw
+	}
+
+	rec := &recorder{ResponseWriter: w, start: time.Now()}
+	flusher, isFlusher := w.(http.Flusher)
+	hijacker, isHijacker := w.(http.Hijacker)
+	readerFrom, isReaderFrom := w.(io.ReaderFrom)
+	f := flushWriter{rec, flusher}
+	h := hijackWriter{rec, hijacker}
+	r := readFromWriter{rec, readerFrom}
+
+	// 只暴露原始ResponseWriter支持的接口，避免调用方通过类型断言误判
+	switch {
+	case isFlusher && isHijacker && isReaderFrom:
+		return struct {
+			*recorder
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{rec, f, h, r}
+	case isFlusher && isHijacker:
+		return struct {
+			*recorder
+			http.Flusher
+			http.Hijacker
+		}{rec, f, h}
+	case isFlusher && isReaderFrom:
+		return struct {
+			*recorder
+			http.Flusher
+			io.ReaderFrom
+		}{rec, f, r}
+	case isHijacker && isReaderFrom:
+		return struct {
+			*recorder
+			http.Hijacker
+			io.ReaderFrom
+		}{rec, h, r}
+	case isFlusher:
+		return struct {
+			*recorder
+			http.Flusher
+		}{rec, f}
+	case isHijacker:
+		return struct {
+			*recorder
+			http.Hijacker
+		}{rec, h}
+	case isReaderFrom:
+		return struct {
+			*recorder
+			io.ReaderFrom
+		}{rec, r}
+	default:
+		return rec
+	}
+}
+
+// recorder 记录响应信息
+type recorder struct {
+	http.ResponseWriter
+	start  time.Time
+	status int
+	bytes  int64
+	ttfb   time.Duration
+}
+
+// WriteHeader 记录状态码，1xx信息响应（101除外）之后还会有最终的响应头，不记录
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		if code >= 200 || code == http.StatusSwitchingProtocols {
+			r.markWritten(code)
+		}
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write 没有调用WriteHeader时，与net/http一样按200处理
+func (r *recorder) Write(b []byte) (int, error) {
+	r.markWritten(http.StatusOK)
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *recorder) markWritten(code int) {
+	if r.status == 0 {
+		r.status = code
+		r.ttfb = time.Since(r.start)
+	}
+}
+
+func (r *recorder) Status() int                 { return r.status }
+func (r *recorder) BytesWritten() int64         { return r.bytes }
+func (r *recorder) TTFB() time.Duration         { return r.ttfb }
+func (r *recorder) Written() bool               { return r.status != 0 }
+func (r *recorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// flushWriter Flush会写出响应头
+type flushWriter struct {
+	*recorder
+	f http.Flusher
+}
+
+func (w flushWriter) Flush() {
+	w.markWritten(http.StatusOK)
+	w.f.Flush()
+}
+
+// hijackWriter 接管连接后由调用方直接写入，按101记录，如WebSocket
+type hijackWriter struct {
+	*recorder
+	h http.Hijacker
+}
+
+func (w hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.h.Hijack()
+	if err == nil {
+		w.markWritten(http.StatusSwitchingProtocols)
+	}
+	return conn, rw, err
+}
+
+// readFromWriter 保留io.ReaderFrom，http.ServeContent等可以使用sendfile
+type readFromWriter struct {
+	*recorder
+	rf io.ReaderFrom
+}
+
+func (w readFromWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.markWritten(http.StatusOK)
+	n, err := w.rf.ReadFrom(src)
+	w.bytes += n
+	return n, err
+}
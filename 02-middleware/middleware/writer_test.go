@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// plainWriter 只实现http.ResponseWriter
+type plainWriter struct {
+	header http.Header
+	buf    bytes.Buffer
+	code   int
+}
+
+func (w *plainWriter) Header() http.Header         { return w.header }
+func (w *plainWriter) Write(b []byte) (int, error) { return w.buf.Write(b) }
+func (w *plainWriter) WriteHeader(code int)        { w.code = code }
+
+// hijackableWriter 只额外实现http.Hijacker
+type hijackableWriter struct{ plainWriter }
+
+func (w *hijackableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	c1, _ := net.Pipe()
+	return c1, nil, nil
+}
+
+func TestWrapWriterInterfaces(t *testing.T) {
+	tests := []struct {
+		name                      string
+		w                         http.ResponseWriter
+		flusher, hijacker, reader bool
+	}{
+		{"recorder", httptest.NewRecorder(), true, false, false},
+		{"plain", &plainWriter{header: http.Header{}}, false, false, false},
+		{"hijacker", &hijackableWriter{plainWriter{header: http.Header{}}}, false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := WrapWriter(tt.w)
+			_, f := rw.(http.Flusher)
+			_, h := rw.(http.Hijacker)
+			_, r := rw.(io.ReaderFrom)
+			if f != tt.flusher || h != tt.hijacker || r != tt.reader {
+				t.Fatalf("Flusher=%v Hijacker=%v ReaderFrom=%v", f, h, r)
+			}
+			if WrapWriter(rw) != rw {
+				t.Fatal("WrapWriter wrapped twice")
+			}
+		})
+	}
+}
+
+func TestWrapWriterRecords(t *testing.T) {
+	w := &plainWriter{header: http.Header{}}
+	rw := WrapWriter(w)
+	if rw.Written() || rw.Status() != 0 {
+		t.Fatal("written before any write")
+	}
+
+	// 1xx信息响应不是最终的状态码
+	rw.WriteHeader(http.StatusEarlyHints)
+	if rw.Written() {
+		t.Fatal("103 recorded as final status")
+	}
+	rw.WriteHeader(http.StatusCreated)
+	rw.Write([]byte("hello"))
+	rw.Write([]byte(", world"))
+	rw.WriteHeader(http.StatusInternalServerError) // 重复调用不改变状态码
+
+	if rw.Status() != http.StatusCreated || rw.BytesWritten() != 12 || rw.TTFB() <= 0 {
+		t.Fatalf("Status=%d Bytes=%d TTFB=%v", rw.Status(), rw.BytesWritten(), rw.TTFB())
+	}
+	if rw.Unwrap() != w {
+		t.Fatal("Unwrap() returned another writer")
+	}
+}
+
+// result 处理函数结束时记录的响应信息
+type result struct {
+	status   int
+	bytes    int64
+	hijacked bool
+}
+
+func TestWrapWriterServer(t *testing.T) {
+	results := make(chan result, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := WrapWriter(w)
+		var hijacked bool
+		defer func() { results <- result{rw.Status(), rw.BytesWritten(), hijacked} }()
+
+		switch r.URL.Path {
+		case "/flush":
+			rw.(http.Flusher).Flush()
+		case "/readfrom":
+			rw.(io.ReaderFrom).ReadFrom(strings.NewReader("0123456789"))
+		case "/hijack":
+			conn, _, err := rw.(http.Hijacker).Hijack()
+			if err == nil {
+				hijacked = true
+				conn.Write([]byte("HTTP/1.1 204 No Content\r\n\r\n"))
+				conn.Close()
+			}
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path string
+		want result
+	}{
+		{"/flush", result{http.StatusOK, 0, false}},
+		{"/readfrom", result{http.StatusOK, 10, false}},
+		{"/hijack", result{http.StatusSwitchingProtocols, 0, true}},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		// 客户端可能在处理函数返回之前就读完了响应，等待处理函数结束
+		if got := <-results; got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"03-logrus/hooks"
+	"03-logrus/logrusx"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
-	"runtime"
 	"time"
 
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
@@ -13,50 +17,141 @@ import (
 
 var Log *logrus.Logger
 
+// Hooks 按名称管理的Hook，作为一个Hook添加到Log上，可以在运行时启用、禁用和调整级别
+var Hooks = hooks.NewRegistry()
+
+// samplingHook 配置了采样时使用，退出前输出最后一次汇总
+var samplingHook *hooks.SamplingHook
+
 // InitLogger 初始化日志配置
+// 配置文件路径通过环境变量LOG_CONFIG指定，未指定时使用默认配置
 func InitLogger() {
+	opts, err := LoadOptions(os.Getenv("LOG_CONFIG"))
+	if err != nil {
+		panic("加载日志配置失败: " + err.Error())
+	}
+	if err := InitLoggerWithOptions(opts); err != nil {
+		panic("初始化logger失败: " + err.Error())
+	}
+}
+
+// InitLoggerWithOptions 使用指定配置初始化Log
+func InitLoggerWithOptions(opts *LoggerOptions) error {
+	level, err := logrus.ParseLevel(opts.Level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", opts.Level, err)
+	}
+
+	// 按名称注册Hook，配置中的启用状态和级别在注册时生效
+	registry := hooks.NewRegistry()
+	if err := registry.Configure(opts.Hooks); err != nil {
+		return err
+	}
+	custom := hooks.NewCustomHook(opts.Hooks[CustomHookName].Fields, hooks.RuntimeFields)
+	if err := registry.Register(CustomHookName, custom); err != nil {
+		return err
+	}
+	if opts.Alert != nil {
+		alert, err := hooks.NewAlertHook(*opts.Alert)
+		if err != nil {
+			return err
+		}
+		if err := registry.Register(AlertHookName, alert); err != nil {
+			return err
+		}
+	}
+
+	// 控制台和文件使用各自的格式，文件不可用时回退到stderr
+	outputs, fallback, err := newOutputHooks(opts)
+	if err != nil {
+		return err
+	}
+
 	Log = logrus.New()
 
-	// 设置日志格式
-	Log.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat:   "2006-01-02 15:04:05",
-		DisableTimestamp:  false,
-		DisableHTMLEscape: true,
-		DataKey:           "data",
-		CallerPrettyfier: func(f *runtime.Frame) (string, string) {
-			filename := path.Base(f.File)
-			return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", filename, f.Line)
-		},
-	})
-
-	// 设置输出
-	Log.SetOutput(os.Stdout)
+	// 输出由WriterHook完成，logger本身不再格式化和输出
+	Log.SetFormatter(discardFormatter{})
+	Log.SetOutput(io.Discard)
 
 	// 设置日志级别
-	Log.SetLevel(logrus.DebugLevel)
+	Log.SetLevel(level)
 
 	// 开启调用者信息
 	Log.SetReportCaller(true)
 
-	// 设置日志轮转
-	configLocalFilesystemLogger(
-		"logs/app.log",
-		7*24*time.Hour,
-		time.Hour*24,
-	)
+	// 通过logx输出的日志先修正调用者，再决定是否采样丢弃，然后添加公共字段，公共字段才会出现在输出中
+	Log.AddHook(logrusx.CallerHook{})
+	if samplingHook != nil {
+		_ = samplingHook.Close()
+		samplingHook = nil
+	}
+	if opts.Sampling != nil {
+		samplingHook = hooks.NewSamplingHook(Log, *opts.Sampling)
+		Log.AddHook(samplingHook)
+	}
+	Log.AddHook(registry.Select(isCustomHook))
+	// 控制台和文件是唯一的输出，放在告警、发送到收集端等Hook之前，这些Hook失败或变慢时日志仍然会输出
+	for _, hook := range outputs {
+		Log.AddHook(hook)
+	}
+	Log.AddHook(registry.Select(func(name string) bool { return !isCustomHook(name) }))
+	Hooks = registry
+
+	if fallback != nil {
+		Log.WithError(fallback).Error("config local file system logger error, fall back to stderr")
+	}
+
+	// 发送到日志收集端
+	if opts, ok := shipOptionsFromEnv(); ok {
+		if err := EnableShipping(opts); err != nil {
+			Log.Errorf("enable log shipping error. %+v", err)
+		}
+	}
+	return nil
+}
+
+// isCustomHook 添加公共字段的Hook，需要在输出之前调用
+func isCustomHook(name string) bool {
+	return name == CustomHookName
 }
 
-// 配置日志轮转
-func configLocalFilesystemLogger(filename string, maxAge time.Duration, rotationTime time.Duration) {
+// 配置日志轮转，先按dirMode创建日志目录
+// pattern为strftime格式的文件名，为空时使用 filename.%Y%m%d，filename为指向当前文件的软链
+func configLocalFilesystemLogger(filename, pattern string, maxAge time.Duration, rotationTime time.Duration, dirMode os.FileMode) (io.Writer, error) {
 	baseLogPath := path.Join(filename)
+	if pattern == "" {
+		pattern = filename + ".%Y%m%d"
+	}
+	if err := os.MkdirAll(path.Dir(pattern), dirMode); err != nil {
+		return nil, fmt.Errorf("create log dir: %w", err)
+	}
 	writer, err := rotatelogs.New(
-		filename+".%Y%m%d",
+		pattern,
 		rotatelogs.WithLinkName(baseLogPath),      // 生成软链，指向最新日志文件
 		rotatelogs.WithMaxAge(maxAge),             // 文件最大保存时间
 		rotatelogs.WithRotationTime(rotationTime), // 日志切割时间间隔
 	)
 	if err != nil {
-		Log.Errorf("config local file system logger error. %+v", err)
+		return nil, fmt.Errorf("create rotate logs: %w", err)
+	}
+	return writer, nil
+}
+
+// closeTimeout 退出时等待异步Hook的最长时间
+const closeTimeout = 5 * time.Second
+
+// Close 退出前输出采样汇总，等待异步Hook处理完队列中的日志，并发送剩余的日志
+func Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+
+	var err error
+	if samplingHook != nil {
+		err = samplingHook.Close()
+	}
+	err = errors.Join(err, Hooks.Flush(ctx))
+	if shipWriter != nil {
+		err = errors.Join(err, shipWriter.Close())
 	}
-	Log.SetOutput(writer)
+	return err
 }
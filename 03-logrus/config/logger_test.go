@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// failingHook 模拟告警webhook返回500
+type failingHook struct{}
+
+func (failingHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (failingHook) Fire(*logrus.Entry) error { return errors.New("send alert: unexpected status 500") }
+
+func TestRegistryHookErrorDoesNotLoseOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	opts := DefaultOptions()
+	opts.Console.Enabled = false
+	opts.File.Path = path
+
+	if err := InitLoggerWithOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	var reported []error
+	Hooks.OnError = func(err error) { reported = append(reported, err) }
+	if err := Hooks.Register("failing", failingHook{}); err != nil {
+		t.Fatal(err)
+	}
+
+	Log.Error("database unavailable")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if !strings.Contains(out, "database unavailable") {
+		t.Fatalf("entry not written: %q", out)
+	}
+	// CustomHook在输出之前调用，公共字段仍然在输出中
+	if !strings.Contains(out, `"app_name":"my_app"`) {
+		t.Fatalf("custom fields missing: %q", out)
+	}
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), `hook "failing"`) {
+		t.Fatalf("reported = %v", reported)
+	}
+}
+
+func TestRoutesSharingPathMustAgree(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.log")
+	opts := DefaultOptions()
+	opts.Console.Enabled = false
+	opts.File.Enabled = false
+	opts.Routes = []RouteOptions{
+		{Levels: []string{"error"}, Path: path, Format: FormatJSON, MaxAge: 720 * time.Hour},
+		{Levels: []string{"warning"}, Path: path, Format: FormatJSON, MaxAge: 720 * time.Hour},
+	}
+	if err := InitLoggerWithOptions(opts); err != nil {
+		t.Fatalf("same settings: %v", err)
+	}
+
+	opts.Routes[1].MaxAge = 24 * time.Hour
+	if err := InitLoggerWithOptions(opts); err == nil || !strings.Contains(err.Error(), "conflict") {
+		t.Fatalf("err = %v, want conflict", err)
+	}
+}
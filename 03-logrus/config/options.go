@@ -0,0 +1,104 @@
+package config
+
+import (
+	"01-zap/sampling"
+	"03-logrus/hooks"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoggerOptions 日志配置，可以从YAML文件和环境变量加载
+type LoggerOptions struct {
+	Level    string                       `yaml:"level"`    // 日志级别 trace/debug/info/warn/error/fatal/panic
+	Console  ConsoleOptions               `yaml:"console"`  // 控制台输出
+	File     FileOptions                  `yaml:"file"`     // 文件输出
+	Routes   []RouteOptions               `yaml:"routes"`   // 按级别路由到不同的文件，与File同时生效
+	Hooks    map[string]hooks.HookOptions `yaml:"hooks"`    // 按名称启用、禁用Hook和限制级别
+	Alert    *hooks.AlertOptions          `yaml:"alert"`    // Error及以上的日志发送到webhook，为空不告警
+	Sampling *sampling.Options            `yaml:"sampling"` // 高频日志采样和按字段限流，为空不采样
+}
+
+// ConsoleOptions 控制台输出配置
+type ConsoleOptions struct {
+	Enabled bool   `yaml:"enabled"`
+	Output  string `yaml:"output"` // stdout 或 stderr
+	Format  string `yaml:"format"` // text/json/logfmt/ecs/gelf，text格式在终端中彩色输出
+}
+
+// FileOptions 文件输出配置，按时间轮转
+type FileOptions struct {
+	Enabled      bool          `yaml:"enabled"`
+	Path         string        `yaml:"path"`          // 指向当前文件的软链路径
+	Pattern      string        `yaml:"pattern"`       // strftime格式的文件名，为空时使用 路径.%Y%m%d
+	Format       string        `yaml:"format"`        // text/json/logfmt/ecs/gelf
+	MaxAge       time.Duration `yaml:"max_age"`       // 文件最大保存时间
+	RotationTime time.Duration `yaml:"rotation_time"` // 日志切割时间间隔
+	DirMode      string        `yaml:"dir_mode"`      // 日志目录不存在时创建的权限，八进制，如 "0755"，路由文件同样使用
+}
+
+// RouteOptions 按级别路由的文件输出，级别集合 → 文件 → 格式
+type RouteOptions struct {
+	Levels       []string      `yaml:"levels"`        // 写入该文件的级别，为空写入全部级别
+	Field        string        `yaml:"field"`         // 只写入带有该字段的日志，如 audit，为空不限制
+	Path         string        `yaml:"path"`          // 指向当前文件的软链路径，相同路径的路由共用一个文件，轮转配置必须一致
+	Pattern      string        `yaml:"pattern"`       // strftime格式的文件名，为空时使用 路径.%Y%m%d
+	Format       string        `yaml:"format"`        // text/json/logfmt/ecs/gelf
+	MaxAge       time.Duration `yaml:"max_age"`       // 文件最大保存时间
+	RotationTime time.Duration `yaml:"rotation_time"` // 日志切割时间间隔
+}
+
+// Hook在注册表中的名称
+const (
+	CustomHookName = "custom" // 添加公共字段
+	AlertHookName  = "alert"  // 发送告警
+)
+
+// DefaultOptions 默认配置
+func DefaultOptions() *LoggerOptions {
+	enabled := true
+	return &LoggerOptions{
+		Level:   "debug",
+		Console: ConsoleOptions{Enabled: true, Output: "stdout", Format: FormatText},
+		File: FileOptions{
+			Enabled:      true,
+			Path:         "logs/app.log",
+			Format:       FormatJSON,
+			MaxAge:       7 * 24 * time.Hour,
+			RotationTime: 24 * time.Hour,
+			DirMode:      "0755",
+		},
+		Hooks: map[string]hooks.HookOptions{
+			CustomHookName: {
+				Enabled: &enabled,
+				Fields: map[string]any{
+					"app_name":    "my_app",
+					"environment": "production",
+				},
+			},
+		},
+	}
+}
+
+// LoadOptions 加载日志配置
+// 先使用默认配置，再用YAML文件覆盖（path为空则跳过），最后用LOG_开头的环境变量覆盖
+func LoadOptions(path string) (*LoggerOptions, error) {
+	opts := DefaultOptions()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read logger config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, opts); err != nil {
+			return nil, fmt.Errorf("parse logger config %s: %w", path, err)
+		}
+	}
+
+	if v, ok := os.LookupEnv("LOG_LEVEL"); ok {
+		opts.Level = v
+	}
+	return opts, nil
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"03-logrus/formatters"
+	"03-logrus/hooks"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 日志格式
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+	FormatECS    = "ecs"  // Elastic Common Schema
+	FormatGELF   = "gelf" // Graylog Extended Log Format
+)
+
+// newFormatter 根据名称创建Formatter，color只对text格式生效
+func newFormatter(format string, color bool) (logrus.Formatter, error) {
+	switch format {
+	case FormatText, "":
+		return &logrus.TextFormatter{
+			ForceColors:      color,
+			DisableColors:    !color,
+			FullTimestamp:    true,
+			TimestampFormat:  "2006-01-02 15:04:05",
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	case FormatJSON:
+		return newJSONFormatter(), nil
+	case FormatLogfmt:
+		return &formatters.LogfmtFormatter{
+			TimestampFormat:  "2006-01-02 15:04:05",
+			DataKey:          "data",
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	case FormatECS:
+		return &formatters.ECSFormatter{
+			DataKey:          "data",
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	case FormatGELF:
+		return &formatters.GELFFormatter{
+			DataKey:          "data",
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// newJSONFormatter 文件和收集端使用的JSON格式
+func newJSONFormatter() *logrus.JSONFormatter {
+	return &logrus.JSONFormatter{
+		TimestampFormat:   "2006-01-02 15:04:05",
+		DisableTimestamp:  false,
+		DisableHTMLEscape: true,
+		DataKey:           "data",
+		CallerPrettyfier:  callerPrettyfier,
+	}
+}
+
+// callerPrettyfier 调用者只保留文件名
+func callerPrettyfier(f *runtime.Frame) (string, string) {
+	filename := path.Base(f.File)
+	return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", filename, f.Line)
+}
+
+// discardFormatter 输出全部由WriterHook完成时使用，避免logger本身重复格式化
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+// consoleWriter 返回控制台输出和是否是终端
+func consoleWriter(output string) (*os.File, bool, error) {
+	var f *os.File
+	switch output {
+	case "stdout", "":
+		f = os.Stdout
+	case "stderr":
+		f = os.Stderr
+	default:
+		return nil, false, fmt.Errorf("unknown console output %q", output)
+	}
+	info, err := f.Stat()
+	return f, err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+}
+
+// parseFileMode 解析八进制的权限，如 "0755"，为空时使用0755
+func parseFileMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0o755, nil
+	}
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q: %w", s, err)
+	}
+	return os.FileMode(mode), nil
+}
+
+// newOutputHooks 为控制台、文件和按级别路由的文件分别创建WriterHook
+// 文件不可用时回退到stderr，fallback说明回退原因，由调用方在logger初始化后记录
+func newOutputHooks(opts *LoggerOptions) (outputs []logrus.Hook, fallback error, err error) {
+	if opts.Console.Enabled {
+		w, tty, err := consoleWriter(opts.Console.Output)
+		if err != nil {
+			return nil, nil, err
+		}
+		formatter, err := newFormatter(opts.Console.Format, tty)
+		if err != nil {
+			return nil, nil, err
+		}
+		outputs = append(outputs, hooks.NewWriterHook(w, formatter))
+	}
+
+	dirMode, err := parseFileMode(opts.File.DirMode)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := &fileWriters{
+		dirMode:       dirMode,
+		defaults:      opts.File,
+		writers:       make(map[string]io.Writer),
+		settings:      make(map[string]rotation),
+		consoleStderr: opts.Console.Enabled && opts.Console.Output == "stderr",
+	}
+
+	if opts.File.Enabled {
+		route := RouteOptions{
+			Path:         opts.File.Path,
+			Pattern:      opts.File.Pattern,
+			Format:       opts.File.Format,
+			MaxAge:       opts.File.MaxAge,
+			RotationTime: opts.File.RotationTime,
+		}
+		hook, err := files.hook(route)
+		if err != nil {
+			return nil, nil, err
+		}
+		if hook != nil {
+			outputs = append(outputs, hook)
+		}
+	}
+
+	for _, route := range opts.Routes {
+		hook, err := files.hook(route)
+		if err != nil {
+			return nil, nil, err
+		}
+		if hook != nil {
+			outputs = append(outputs, hook)
+		}
+	}
+	return outputs, errors.Join(files.fallbacks...), nil
+}
+
+// fileWriters 创建文件输出，相同路径的输出共用一个轮转writer
+type fileWriters struct {
+	dirMode       os.FileMode
+	defaults      FileOptions // 路由没有设置保存时间和切割间隔时使用File的配置
+	writers       map[string]io.Writer
+	settings      map[string]rotation // 每个路径的轮转配置，共用文件的路由必须一致
+	consoleStderr bool                // 控制台已经输出到stderr
+	fallbacks     []error             // 回退到stderr的原因
+}
+
+// rotation 文件的轮转配置
+type rotation struct {
+	pattern      string
+	maxAge       time.Duration
+	rotationTime time.Duration
+}
+
+// hook 创建写入路由文件的WriterHook，文件不可用时写入stderr，控制台已经输出到stderr时返回nil
+func (f *fileWriters) hook(route RouteOptions) (*hooks.WriterHook, error) {
+	formatter, err := newFormatter(route.Format, false)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := hooks.ParseLevels(route.Levels)
+	if err != nil {
+		return nil, fmt.Errorf("route %s: %w", route.Path, err)
+	}
+
+	if route.MaxAge <= 0 {
+		route.MaxAge = f.defaults.MaxAge
+	}
+	if route.RotationTime <= 0 {
+		route.RotationTime = f.defaults.RotationTime
+	}
+
+	// 相同路径共用一个writer，轮转配置不一致时后面的配置不会生效，直接报错
+	settings := rotation{pattern: route.Pattern, maxAge: route.MaxAge, rotationTime: route.RotationTime}
+	if settings.pattern == "" {
+		settings.pattern = route.Path + ".%Y%m%d"
+	}
+	if prev, ok := f.settings[route.Path]; ok && prev != settings {
+		return nil, fmt.Errorf("route %s: pattern, max_age and rotation_time conflict with another output of the same path", route.Path)
+	}
+	f.settings[route.Path] = settings
+
+	w, ok := f.writers[route.Path]
+	if !ok {
+		w, err = configLocalFilesystemLogger(route.Path, route.Pattern, route.MaxAge, route.RotationTime, f.dirMode)
+		if err != nil {
+			f.fallbacks = append(f.fallbacks, fmt.Errorf("%s: %w", route.Path, err))
+			if f.consoleStderr {
+				return nil, nil
+			}
+			w = os.Stderr
+		}
+		f.writers[route.Path] = w
+	}
+
+	hook := hooks.NewWriterHook(w, formatter, levels...)
+	if route.Field != "" {
+		hook.Filter = func(entry *logrus.Entry) bool {
+			_, ok := entry.Data[route.Field]
+			return ok
+		}
+	}
+	return hook, nil
+}
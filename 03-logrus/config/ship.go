@@ -0,0 +1,71 @@
+package config
+
+import (
+	"01-zap/ship"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ShipHookName 发送到收集端的Hook在注册表中的名称
+const ShipHookName = "ship"
+
+// shipWriter 当前启用的收集端writer，Close时发送剩余的日志
+var shipWriter *ship.Writer
+
+// ShipHook 把日志按JSON格式发送到收集端，不影响原有的输出和格式
+type ShipHook struct {
+	Writer    *ship.Writer
+	Formatter logrus.Formatter
+	LogLevels []logrus.Level
+}
+
+// Fire 格式化后放入发送队列，队列满时丢弃，不会阻塞
+func (h *ShipHook) Fire(entry *logrus.Entry) error {
+	b, err := h.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.Writer.Write(b)
+	return err
+}
+
+// Levels 定义Hook处理的日志级别
+func (h *ShipHook) Levels() []logrus.Level {
+	if len(h.LogLevels) == 0 {
+		return logrus.AllLevels
+	}
+	return h.LogLevels
+}
+
+// EnableShipping 把日志批量发送到收集端，Hook以 ShipHookName 注册到Hooks，可以通过配置限制级别
+//
+//	config.EnableShipping(ship.Options{Endpoint: "http://127.0.0.1:9880/logs", Gzip: true, QueueDir: "logs/ship-queue"})
+func EnableShipping(opts ship.Options) error {
+	w, err := ship.New(opts)
+	if err != nil {
+		return err
+	}
+	if err := Hooks.Register(ShipHookName, &ShipHook{Writer: w, Formatter: newJSONFormatter()}); err != nil {
+		w.Close()
+		return err
+	}
+	if shipWriter != nil {
+		shipWriter.Close()
+	}
+	shipWriter = w
+	return nil
+}
+
+// shipOptionsFromEnv 通过环境变量 LOG_SHIP_ENDPOINT 启用发送，LOG_SHIP_QUEUE_DIR 指定磁盘队列目录
+func shipOptionsFromEnv() (ship.Options, bool) {
+	endpoint := os.Getenv("LOG_SHIP_ENDPOINT")
+	if endpoint == "" {
+		return ship.Options{}, false
+	}
+	return ship.Options{
+		Endpoint: endpoint,
+		Gzip:     true,
+		QueueDir: os.Getenv("LOG_SHIP_QUEUE_DIR"),
+	}, true
+}
@@ -0,0 +1,104 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ECSVersion 输出的Elastic Common Schema版本
+const ECSVersion = "8.11.0"
+
+// ECSFormatter 按Elastic Common Schema的字段名输出JSON，如
+//
+//	{"@timestamp":"2025-01-17T10:00:00.000Z","ecs.version":"8.11.0","log.level":"info","message":"...","log.origin.function":"main.main()","log.origin.file.name":"main.go:28"}
+//
+// 日志中的error字段输出为error.message
+type ECSFormatter struct {
+	TimestampFormat  string                                       // 时间格式，默认带毫秒的RFC3339
+	DataKey          string                                       // 字段放在该key下，为空时放在顶层
+	CallerPrettyfier func(*runtime.Frame) (function, file string) // 自定义调用者输出，此时不再单独输出行号
+}
+
+const ecsTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Format 实现logrus.Formatter
+func (f *ECSFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	layout := f.TimestampFormat
+	if layout == "" {
+		layout = ecsTimestampFormat
+	}
+
+	out := map[string]any{
+		"@timestamp":  entry.Time.Format(layout),
+		"ecs.version": ECSVersion,
+		"log.level":   entry.Level.String(),
+		"message":     entry.Message,
+	}
+	if entry.HasCaller() {
+		if f.CallerPrettyfier != nil {
+			function, file := f.CallerPrettyfier(entry.Caller)
+			setNonEmpty(out, "log.origin.function", function)
+			setNonEmpty(out, "log.origin.file.name", file)
+		} else {
+			out["log.origin.function"] = entry.Caller.Function
+			out["log.origin.file.name"] = entry.Caller.File
+			out["log.origin.file.line"] = entry.Caller.Line
+		}
+	}
+
+	data := make(map[string]any, len(entry.Data))
+	for k, v := range entry.Data {
+		if k == logrus.ErrorKey {
+			if err, ok := v.(error); ok {
+				out["error.message"] = err.Error()
+				continue
+			}
+		}
+		data[k] = fieldValue(v)
+	}
+	if f.DataKey != "" {
+		if len(data) > 0 {
+			out[f.DataKey] = data
+		}
+	} else {
+		for k, v := range data {
+			if _, ok := out[k]; ok || strings.HasPrefix(k, "@") {
+				k = "fields." + k
+			}
+			out[k] = v
+		}
+	}
+
+	return encodeJSON(entry, out)
+}
+
+func setNonEmpty(m map[string]any, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+// encodeJSON 编码为一行JSON，不转义HTML字符
+func encodeJSON(entry *logrus.Entry, v any) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// unixSeconds 带毫秒精度的Unix秒数
+func unixSeconds(t time.Time) json.Number {
+	return json.Number(strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', 3, 64))
+}
@@ -0,0 +1,52 @@
+// Package formatters 提供logfmt、ECS和GELF格式的logrus.Formatter
+//
+// 与logrus.JSONFormatter一样支持DataKey把字段放在单独的key下，
+// 支持CallerPrettyfier自定义调用者的输出方式，可以直接替换config中的JSON格式。
+package formatters
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// caller 返回调用者的函数和文件，CallerPrettyfier为空时文件包含完整路径和行号
+func caller(entry *logrus.Entry, prettyfier func(*runtime.Frame) (string, string)) (function, file string, ok bool) {
+	if !entry.HasCaller() {
+		return "", "", false
+	}
+	if prettyfier != nil {
+		function, file = prettyfier(entry.Caller)
+		return function, file, true
+	}
+	return entry.Caller.Function, entry.Caller.File + ":" + strconv.Itoa(entry.Caller.Line), true
+}
+
+// fieldValue 把字段值转换为可以编码为JSON的值，error转换为错误信息
+func fieldValue(v any) any {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case json.Marshaler:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return v
+}
+
+// prefixClash logrus保留的key，字段与之同名且没有DataKey时加上 fields. 前缀，与logrus的行为一致
+func prefixClash(key string, reserved ...string) string {
+	for _, r := range reserved {
+		if key == r {
+			return "fields." + key
+		}
+	}
+	return key
+}
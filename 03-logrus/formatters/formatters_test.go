@@ -0,0 +1,107 @@
+package formatters
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// go test ./formatters -update 重新生成testdata中的golden文件
+var update = flag.Bool("update", false, "update golden files")
+
+func prettyfier(f *runtime.Frame) (string, string) {
+	return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", path.Base(f.File), f.Line)
+}
+
+// testEntry 固定时间和调用者的日志，覆盖常见的字段类型和与保留key同名的字段
+func testEntry(msg string) *logrus.Entry {
+	logger := logrus.New()
+	logger.SetReportCaller(true)
+
+	entry := logger.WithFields(logrus.Fields{
+		"user_id":       42,
+		"name":          "张 三",
+		"ok":            true,
+		"elapsed":       1500 * time.Millisecond,
+		"tags":          []string{"a", "b"},
+		"msg":           "clash",
+		"id":            "req-1",
+		logrus.ErrorKey: errors.New("connection refused"),
+	})
+	entry.Time = time.Date(2025, 1, 17, 10, 0, 0, 123000000, time.UTC)
+	entry.Level = logrus.WarnLevel
+	entry.Message = msg
+	entry.Caller = &runtime.Frame{Function: "main.main", File: "/src/app/main.go", Line: 28}
+	return entry
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter logrus.Formatter
+		msg       string
+	}{
+		{"logfmt", &LogfmtFormatter{}, "user login"},
+		{"logfmt_data", &LogfmtFormatter{TimestampFormat: "2006-01-02 15:04:05", DataKey: "data", CallerPrettyfier: prettyfier}, "user login"},
+		{"ecs", &ECSFormatter{}, "user login"},
+		{"ecs_data", &ECSFormatter{DataKey: "data", CallerPrettyfier: prettyfier}, "user login"},
+		{"gelf", &GELFFormatter{Host: "test-host"}, "user login\nsecond line"},
+		{"gelf_data", &GELFFormatter{Host: "test-host", DataKey: "data", CallerPrettyfier: prettyfier}, "user login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.formatter.Format(testEntry(tt.msg))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("output mismatch\ngot:  %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestNoCaller(t *testing.T) {
+	entry := testEntry("user login")
+	entry.Logger.SetReportCaller(false)
+
+	for _, f := range []logrus.Formatter{&LogfmtFormatter{}, &ECSFormatter{}, &GELFFormatter{}} {
+		got, err := f.Format(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := string(got); containsAny(s, "main.go", "main.main") {
+			t.Errorf("%T: unexpected caller in %s", f, s)
+		}
+	}
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package formatters
+
+import (
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GELFFormatter 输出Graylog Extended Log Format 1.1，如
+//
+//	{"version":"1.1","host":"vm","short_message":"...","timestamp":1737079200.000,"level":6,"_file":"main.go:28","_func":"main.main()","_data.port":8080}
+//
+// 自定义字段加上 _ 前缀放在顶层，设置DataKey时为 _data.port 形式；多行消息的第一行作为short_message
+type GELFFormatter struct {
+	Host             string                                       // 为空时使用os.Hostname
+	DataKey          string                                       // 字段名前缀
+	CallerPrettyfier func(*runtime.Frame) (function, file string) // 自定义调用者输出
+}
+
+// gelfInvalidChars GELF字段名只能包含字母、数字、下划线、点和横线
+var gelfInvalidChars = regexp.MustCompile(`[^\w.\-]`)
+
+// Format 实现logrus.Formatter
+func (f *GELFFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	host := f.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	short, _, multiline := strings.Cut(entry.Message, "\n")
+	out := map[string]any{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": short,
+		"timestamp":     unixSeconds(entry.Time),
+		"level":         syslogLevel(entry.Level),
+		"_level_name":   entry.Level.String(),
+	}
+	if multiline {
+		out["full_message"] = entry.Message
+	}
+	if function, file, ok := caller(entry, f.CallerPrettyfier); ok {
+		setNonEmpty(out, "_func", function)
+		setNonEmpty(out, "_file", file)
+	}
+
+	for k, v := range entry.Data {
+		key := k
+		if f.DataKey != "" {
+			key = f.DataKey + "." + k
+		}
+		key = "_" + gelfInvalidChars.ReplaceAllString(key, "_")
+		// _id 是GELF保留的字段名
+		if key == "_id" {
+			key = "_fields.id"
+		}
+		if _, ok := out[key]; ok {
+			key = "_fields." + key[1:]
+		}
+		out[key] = gelfValue(v)
+	}
+
+	return encodeJSON(entry, out)
+}
+
+// gelfValue GELF的自定义字段只能是字符串或数字
+func gelfValue(v any) any {
+	switch v := fieldValue(v).(type) {
+	case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	default:
+		return logfmtValue(v)
+	}
+}
+
+// syslogLevel logrus级别对应的syslog级别
+func syslogLevel(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 0 // Emergency
+	case logrus.FatalLevel:
+		return 2 // Critical
+	case logrus.ErrorLevel:
+		return 3 // Error
+	case logrus.WarnLevel:
+		return 4 // Warning
+	case logrus.InfoLevel:
+		return 6 // Informational
+	default:
+		return 7 // Debug
+	}
+}
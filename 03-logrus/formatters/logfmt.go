@@ -0,0 +1,111 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogfmtFormatter 输出logfmt格式，如
+//
+//	time="2025-01-17 10:00:00" level=info msg="server starting..." func=main.main() file=main.go:28 data.port=8080
+type LogfmtFormatter struct {
+	TimestampFormat  string                                       // 时间格式，默认 time.RFC3339
+	DisableTimestamp bool                                         // 不输出时间
+	DataKey          string                                       // 字段名前缀，如 data 时输出 data.port=8080
+	CallerPrettyfier func(*runtime.Frame) (function, file string) // 自定义调用者输出
+}
+
+// Format 实现logrus.Formatter
+func (f *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+
+	if !f.DisableTimestamp {
+		layout := f.TimestampFormat
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		writePair(b, logrus.FieldKeyTime, entry.Time.Format(layout))
+	}
+	writePair(b, logrus.FieldKeyLevel, entry.Level.String())
+	writePair(b, logrus.FieldKeyMsg, entry.Message)
+	if function, file, ok := caller(entry, f.CallerPrettyfier); ok {
+		if function != "" {
+			writePair(b, logrus.FieldKeyFunc, function)
+		}
+		if file != "" {
+			writePair(b, logrus.FieldKeyFile, file)
+		}
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := k
+		if f.DataKey != "" {
+			key = f.DataKey + "." + k
+		} else {
+			key = prefixClash(k, logrus.FieldKeyTime, logrus.FieldKeyLevel, logrus.FieldKeyMsg, logrus.FieldKeyFunc, logrus.FieldKeyFile)
+		}
+		writePair(b, key, logfmtValue(entry.Data[k]))
+	}
+
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// writePair 写入 key=value，value需要时加引号
+func writePair(b *bytes.Buffer, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if needsQuote(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+// logfmtValue 字段值转换为字符串，结构体、map等使用JSON
+func logfmtValue(v any) string {
+	switch v := fieldValue(v).(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// needsQuote 空字符串、包含空格、引号、等号或控制字符时需要加引号
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r == ' ' || r == '"' || r == '=' || r == '\\' || unicode.IsControl(r) || !unicode.IsPrint(r)
+	}) >= 0
+}
@@ -0,0 +1,395 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 告警消息格式
+const (
+	AlertFormatJSON     = "json"     // 通用JSON
+	AlertFormatSlack    = "slack"    // Slack Incoming Webhook
+	AlertFormatDingTalk = "dingtalk" // 钉钉自定义机器人
+	AlertFormatFeishu   = "feishu"   // 飞书自定义机器人
+)
+
+// AlertOptions 告警Hook配置
+type AlertOptions struct {
+	URL     string        `yaml:"url"`     // webhook地址
+	Format  string        `yaml:"format"`  // json/slack/dingtalk/feishu，默认 json
+	Window  time.Duration `yaml:"window"`  // 相同告警的合并窗口，窗口内只发送第一条和一条汇总，默认 1m
+	Timeout time.Duration `yaml:"timeout"` // 单次请求超时，默认 5s
+	// MaxAlerts 每个窗口内最多立即发送多少条不同的告警，超出的合并到窗口结束时的一条汇总中，默认 10，小于0不限制
+	MaxAlerts int `yaml:"max_alerts"`
+
+	Client *http.Client `yaml:"-"` // 默认使用带Timeout的http.Client
+}
+
+const (
+	defaultAlertWindow    = time.Minute
+	defaultAlertTimeout   = 5 * time.Second
+	defaultAlertMaxAlerts = 10
+)
+
+// Alert 一条告警，Count大于1时是窗口内的汇总
+type Alert struct {
+	Level       string         `json:"level"`
+	Message     string         `json:"message"`
+	Caller      string         `json:"caller,omitempty"`
+	Fingerprint string         `json:"fingerprint"`
+	Count       int            `json:"count"`      // 本次告警代表的条数
+	FirstSeen   time.Time      `json:"first_seen"` // 窗口内第一次出现的时间
+	LastSeen    time.Time      `json:"last_seen"`  // 窗口内最后一次出现的时间
+	Fields      map[string]any `json:"fields,omitempty"`
+	Summary     bool           `json:"summary"` // 是否是窗口结束时的汇总
+	// Suppressed 超过MaxAlerts被合并的告警，消息到条数，只出现在限流的汇总中
+	Suppressed map[string]int `json:"suppressed,omitempty"`
+}
+
+// AlertHook Error及以上的日志发送到webhook
+// 相同的消息和调用位置（需要SetReportCaller(true)）在窗口内合并：第一条立即发送，
+// 之后的只计数，窗口结束时发送一条汇总，避免同一个错误刷屏；
+// 每个窗口内最多立即发送MaxAlerts条不同的告警，超出的同样只计数，窗口结束时合并为一条汇总
+// 发送是同步的，建议通过AsyncHook或配置中的async使用
+type AlertHook struct {
+	opts   AlertOptions
+	client *http.Client
+
+	// OnError 发送失败时的回调，默认输出到stderr，不能再写入同一个logger
+	// Fire始终返回nil，避免logrus因为告警失败而不再调用后面的Hook
+	OnError func(error)
+
+	mu       sync.Mutex
+	groups   map[string]*alertGroup
+	limitEnd time.Time   // 当前限流窗口的结束时间
+	sent     int         // 当前限流窗口内立即发送的告警数
+	overflow *alertGroup // 超过MaxAlerts被合并的告警，为nil表示没有
+}
+
+// alertGroup 窗口内相同指纹的告警
+type alertGroup struct {
+	alert Alert
+	timer *time.Timer
+}
+
+// NewAlertHook 创建告警Hook
+func NewAlertHook(opts AlertOptions) (*AlertHook, error) {
+	if opts.URL == "" {
+		return nil, errors.New("alert webhook url is required")
+	}
+	switch opts.Format {
+	case "":
+		opts.Format = AlertFormatJSON
+	case AlertFormatJSON, AlertFormatSlack, AlertFormatDingTalk, AlertFormatFeishu:
+	default:
+		return nil, fmt.Errorf("unknown alert format %q", opts.Format)
+	}
+	if opts.Window <= 0 {
+		opts.Window = defaultAlertWindow
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultAlertTimeout
+	}
+	if opts.MaxAlerts == 0 {
+		opts.MaxAlerts = defaultAlertMaxAlerts
+	}
+
+	h := &AlertHook{
+		opts:   opts,
+		client: opts.Client,
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Failed to send alert: %v\n", err)
+		},
+		groups: make(map[string]*alertGroup),
+	}
+	if h.client == nil {
+		h.client = &http.Client{Timeout: opts.Timeout}
+	}
+	return h, nil
+}
+
+// Levels 只处理Error及以上的日志
+func (h *AlertHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+// Fire 窗口内第一次出现时立即发送，之后只计数
+func (h *AlertHook) Fire(entry *logrus.Entry) error {
+	caller := ""
+	if entry.Caller != nil {
+		caller = fmt.Sprintf("%s:%d %s", entry.Caller.File, entry.Caller.Line, entry.Caller.Function)
+	}
+	fp := fingerprint(entry.Message, caller)
+
+	h.mu.Lock()
+	if g, ok := h.groups[fp]; ok {
+		g.alert.Count++
+		g.alert.LastSeen = entry.Time
+		h.mu.Unlock()
+		return nil
+	}
+	if !h.allowLocked() {
+		h.suppressLocked(entry)
+		h.mu.Unlock()
+		return nil
+	}
+
+	alert := Alert{
+		Level:       entry.Level.String(),
+		Message:     entry.Message,
+		Caller:      caller,
+		Fingerprint: fp,
+		Count:       1,
+		FirstSeen:   entry.Time,
+		LastSeen:    entry.Time,
+		Fields:      alertFields(entry.Data),
+	}
+	g := &alertGroup{alert: alert}
+	g.timer = time.AfterFunc(h.opts.Window, func() { h.summarize(fp) })
+	h.groups[fp] = g
+	h.mu.Unlock()
+
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := h.send(ctx, alert); err != nil {
+		h.OnError(err)
+	}
+	return nil
+}
+
+// allowLocked 判断当前限流窗口内是否还能立即发送，调用方持有mu
+func (h *AlertHook) allowLocked() bool {
+	if h.opts.MaxAlerts < 0 {
+		return true
+	}
+	now := time.Now()
+	if !now.Before(h.limitEnd) {
+		h.limitEnd = now.Add(h.opts.Window)
+		h.sent = 0
+	}
+	if h.sent >= h.opts.MaxAlerts {
+		return false
+	}
+	h.sent++
+	return true
+}
+
+// suppressLocked 把超过限制的告警合并到窗口结束时的汇总中，调用方持有mu
+func (h *AlertHook) suppressLocked(entry *logrus.Entry) {
+	if h.overflow == nil {
+		h.overflow = &alertGroup{alert: Alert{
+			Level:       entry.Level.String(),
+			Message:     "告警过多，超出限制的告警已合并",
+			Fingerprint: "rate_limited",
+			FirstSeen:   entry.Time,
+			Summary:     true,
+			Suppressed:  make(map[string]int),
+		}}
+		h.overflow.timer = time.AfterFunc(time.Until(h.limitEnd), h.summarizeOverflow)
+	}
+	a := &h.overflow.alert
+	// logrus中级别的值越小越严重
+	if level, err := logrus.ParseLevel(a.Level); err == nil && entry.Level < level {
+		a.Level = entry.Level.String()
+	}
+	a.Count++
+	a.LastSeen = entry.Time
+	a.Suppressed[entry.Message]++
+}
+
+// summarizeOverflow 限流窗口结束时发送被合并的告警
+func (h *AlertHook) summarizeOverflow() {
+	h.mu.Lock()
+	g := h.overflow
+	h.overflow = nil
+	h.mu.Unlock()
+
+	if g == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.opts.Timeout)
+	defer cancel()
+	if err := h.send(ctx, g.alert); err != nil {
+		h.OnError(err)
+	}
+}
+
+// summarize 窗口结束时发送汇总，窗口内只出现一次时不发送
+func (h *AlertHook) summarize(fp string) {
+	h.mu.Lock()
+	g, ok := h.groups[fp]
+	if ok {
+		delete(h.groups, fp)
+	}
+	h.mu.Unlock()
+
+	if !ok || g.alert.Count <= 1 {
+		return
+	}
+	alert := g.alert
+	alert.Count--
+	alert.Summary = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.opts.Timeout)
+	defer cancel()
+	if err := h.send(ctx, alert); err != nil {
+		h.OnError(err)
+	}
+}
+
+// Flush 立即发送所有窗口的汇总，用于退出前
+func (h *AlertHook) Flush(ctx context.Context) error {
+	h.mu.Lock()
+	groups := h.groups
+	h.groups = make(map[string]*alertGroup)
+	overflow := h.overflow
+	h.overflow = nil
+	h.mu.Unlock()
+
+	var errs []error
+	if overflow != nil {
+		overflow.timer.Stop()
+		if err := h.send(ctx, overflow.alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, g := range groups {
+		g.timer.Stop()
+		if g.alert.Count <= 1 {
+			continue
+		}
+		alert := g.alert
+		alert.Count--
+		alert.Summary = true
+		if err := h.send(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send 按配置的格式发送告警
+func (h *AlertHook) send(ctx context.Context, alert Alert) error {
+	body, err := h.payload(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send alert: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("send alert: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// payload 生成webhook请求体
+func (h *AlertHook) payload(alert Alert) ([]byte, error) {
+	switch h.opts.Format {
+	case AlertFormatSlack:
+		return json.Marshal(map[string]any{"text": alertText(alert)})
+	case AlertFormatDingTalk:
+		return json.Marshal(map[string]any{
+			"msgtype": "text",
+			"text":    map[string]string{"content": alertText(alert)},
+		})
+	case AlertFormatFeishu:
+		return json.Marshal(map[string]any{
+			"msg_type": "text",
+			"content":  map[string]string{"text": alertText(alert)},
+		})
+	default:
+		return json.Marshal(alert)
+	}
+}
+
+// alertText 机器人消息的文本内容
+func alertText(alert Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", strings.ToUpper(alert.Level), alert.Message)
+	if alert.Caller != "" {
+		fmt.Fprintf(&b, "\ncaller: %s", alert.Caller)
+	}
+
+	keys := make([]string, 0, len(alert.Fields))
+	for k := range alert.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\n%s: %v", k, alert.Fields[k])
+	}
+
+	if len(alert.Suppressed) > 0 {
+		fmt.Fprintf(&b, "\n%s 至 %s 期间合并了 %d 条告警",
+			alert.FirstSeen.Format("2006-01-02 15:04:05"),
+			alert.LastSeen.Format("2006-01-02 15:04:05"),
+			alert.Count,
+		)
+		messages := make([]string, 0, len(alert.Suppressed))
+		for m := range alert.Suppressed {
+			messages = append(messages, m)
+		}
+		sort.Strings(messages)
+		for _, m := range messages {
+			fmt.Fprintf(&b, "\n%s: %d 次", m, alert.Suppressed[m])
+		}
+	} else if alert.Summary {
+		fmt.Fprintf(&b, "\n%s 至 %s 期间又出现 %d 次",
+			alert.FirstSeen.Format("2006-01-02 15:04:05"),
+			alert.LastSeen.Format("2006-01-02 15:04:05"),
+			alert.Count,
+		)
+	}
+	return b.String()
+}
+
+// alertFields 复制日志字段，error和不能编码为JSON的值转换为字符串
+func alertFields(data logrus.Fields) map[string]any {
+	if len(data) == 0 {
+		return nil
+	}
+	fields := make(map[string]any, len(data))
+	for k, v := range data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		} else if _, err := json.Marshal(v); err != nil {
+			v = fmt.Sprint(v)
+		}
+		fields[k] = v
+	}
+	return fields
+}
+
+// fingerprint 消息和调用位置相同的告警视为同一个
+func fingerprint(message, caller string) string {
+	sum := sha1.Sum([]byte(message + "\x00" + caller))
+	return hex.EncodeToString(sum[:8])
+}
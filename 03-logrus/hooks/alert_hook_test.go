@@ -0,0 +1,236 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// receiver 记录收到的webhook请求体
+type receiver struct {
+	mu     sync.Mutex
+	bodies []map[string]any
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body map[string]any
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	r.bodies = append(r.bodies, body)
+	r.mu.Unlock()
+}
+
+func (r *receiver) received() []map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]any(nil), r.bodies...)
+}
+
+func newAlertLogger(t *testing.T, opts AlertOptions) (*logrus.Logger, *AlertHook) {
+	t.Helper()
+	hook, err := NewAlertHook(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetReportCaller(true)
+	logger.AddHook(hook)
+	return logger, hook
+}
+
+func TestAlertHookDeduplicates(t *testing.T) {
+	recv := &receiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	logger, _ := newAlertLogger(t, AlertOptions{URL: srv.URL, Window: 100 * time.Millisecond})
+
+	for i := 0; i < 5; i++ {
+		logger.WithError(errors.New("connection refused")).Error("db query failed")
+	}
+	logger.Error("db query failed") // 调用位置不同，单独告警
+	logger.Warn("not an alert")
+
+	alerts := recv.received()
+	if len(alerts) != 2 {
+		t.Fatalf("received %d alerts before the window ends, want 2: %v", len(alerts), alerts)
+	}
+	first := alerts[0]
+	if first["message"] != "db query failed" || first["level"] != "error" || first["count"] != float64(1) {
+		t.Errorf("first alert = %v", first)
+	}
+	if fields, _ := first["fields"].(map[string]any); fields["error"] != "connection refused" {
+		t.Errorf("fields = %v", first["fields"])
+	}
+	if caller, _ := first["caller"].(string); !strings.Contains(caller, "alert_hook_test.go") {
+		t.Errorf("caller = %q", caller)
+	}
+
+	// 窗口结束后发送汇总
+	deadline := time.Now().Add(2 * time.Second)
+	for len(recv.received()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	alerts = recv.received()
+	if len(alerts) != 3 {
+		t.Fatalf("received %d alerts, want 3", len(alerts))
+	}
+	summary := alerts[2]
+	if summary["summary"] != true || summary["count"] != float64(4) || summary["fingerprint"] != first["fingerprint"] {
+		t.Errorf("summary = %v", summary)
+	}
+}
+
+func TestAlertHookFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		text   func(body map[string]any) string
+	}{
+		{AlertFormatSlack, func(b map[string]any) string {
+			s, _ := b["text"].(string)
+			return s
+		}},
+		{AlertFormatDingTalk, func(b map[string]any) string {
+			if b["msgtype"] != "text" {
+				return ""
+			}
+			s, _ := b["text"].(map[string]any)["content"].(string)
+			return s
+		}},
+		{AlertFormatFeishu, func(b map[string]any) string {
+			if b["msg_type"] != "text" {
+				return ""
+			}
+			s, _ := b["content"].(map[string]any)["text"].(string)
+			return s
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			recv := &receiver{}
+			srv := httptest.NewServer(recv)
+			defer srv.Close()
+
+			logger, hook := newAlertLogger(t, AlertOptions{URL: srv.URL, Format: tt.format, Window: time.Hour})
+			for order := 42; order < 44; order++ {
+				logger.WithField("order", order).Error("payment failed")
+			}
+			if err := hook.Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			alerts := recv.received()
+			if len(alerts) != 2 {
+				t.Fatalf("received %d alerts, want 2", len(alerts))
+			}
+			text := tt.text(alerts[0])
+			if !strings.HasPrefix(text, "[ERROR] payment failed\ncaller: ") || !strings.Contains(text, "order: 42") {
+				t.Errorf("text = %q", text)
+			}
+			if summary := tt.text(alerts[1]); !strings.Contains(summary, "又出现 1 次") {
+				t.Errorf("summary = %q", summary)
+			}
+		})
+	}
+}
+
+func TestAlertHookReportsSendErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	hook, err := NewAlertHook(AlertOptions{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []error
+	hook.OnError = func(err error) { reported = append(reported, err) }
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "db query failed"
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("Fire() = %v, want nil", err)
+	}
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "unexpected status 500") {
+		t.Fatalf("reported = %v", reported)
+	}
+}
+
+func TestAlertHookRateLimit(t *testing.T) {
+	recv := &receiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	logger, _ := newAlertLogger(t, AlertOptions{URL: srv.URL, Window: 100 * time.Millisecond, MaxAlerts: 3})
+
+	// 20条不同的告警，只立即发送3条
+	for i := 0; i < 20; i++ {
+		logger.Errorf("order %d failed", i%10)
+	}
+	alerts := recv.received()
+	if len(alerts) != 3 {
+		t.Fatalf("received %d alerts during the burst, want 3", len(alerts))
+	}
+
+	// 窗口结束时超出的告警合并为一条汇总，已发送的告警各自再发送一条重复汇总
+	deadline := time.Now().Add(2 * time.Second)
+	for len(recv.received()) < 7 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	alerts = recv.received()
+	var limited map[string]any
+	for _, a := range alerts[3:] {
+		if a["fingerprint"] == "rate_limited" {
+			limited = a
+		}
+	}
+	if len(alerts) != 7 || limited == nil {
+		t.Fatalf("received %d alerts, want 7 including the rate limit summary: %v", len(alerts), alerts)
+	}
+	suppressed, _ := limited["suppressed"].(map[string]any)
+	if limited["count"] != float64(14) || limited["summary"] != true || len(suppressed) != 7 || suppressed["order 9 failed"] != float64(2) {
+		t.Errorf("rate limit summary = %v", limited)
+	}
+
+	// 下一个窗口重新计数
+	logger.Error("payment failed")
+	if got := len(recv.received()); got != 8 {
+		t.Fatalf("received %d alerts after the window, want 8", got)
+	}
+}
+
+func TestAlertHookFlushRateLimited(t *testing.T) {
+	recv := &receiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	logger, hook := newAlertLogger(t, AlertOptions{URL: srv.URL, Format: AlertFormatSlack, Window: time.Hour, MaxAlerts: 1})
+	logger.Error("db query failed")
+	logger.Error("cache miss")
+	if err := hook.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	alerts := recv.received()
+	if len(alerts) != 2 {
+		t.Fatalf("received %d alerts, want 2", len(alerts))
+	}
+	if text, _ := alerts[1]["text"].(string); !strings.Contains(text, "合并了 1 条告警") || !strings.Contains(text, "cache miss: 1 次") {
+		t.Errorf("text = %q", text)
+	}
+}
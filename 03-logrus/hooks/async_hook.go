@@ -0,0 +1,232 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 队列满时的处理策略
+const (
+	OverflowBlock      = "block"       // 阻塞等待，调用方会被拖慢
+	OverflowDropOldest = "drop_oldest" // 丢弃队列中最早的日志
+	OverflowDropNewest = "drop_newest" // 丢弃当前的日志
+)
+
+// AsyncOptions 异步Hook配置
+type AsyncOptions struct {
+	QueueSize int           `yaml:"queue_size"` // 队列最多缓存的日志条数，默认 1024
+	Workers   int           `yaml:"workers"`    // 并发调用Fire的goroutine数，默认 1
+	Timeout   time.Duration `yaml:"timeout"`    // 单次Fire的超时时间，通过entry.Context传给被包装的Hook，默认 5s
+	Overflow  string        `yaml:"overflow"`   // block/drop_oldest/drop_newest，默认 drop_newest
+}
+
+const (
+	defaultAsyncQueueSize = 1024
+	defaultAsyncTimeout   = 5 * time.Second
+)
+
+// AsyncStats 异步Hook的统计
+type AsyncStats struct {
+	Fired    uint64 // 调用成功的条数
+	Failed   uint64 // Fire返回错误的条数
+	TimedOut uint64 // Fire返回时已经超时的条数
+	Dropped  uint64 // 队列满时丢弃的条数
+	Queued   int    // 队列中等待处理的条数
+}
+
+// AsyncHook 异步调用Hook
+// logrus在调用方的goroutine中依次同步调用每个Hook的Fire（在锁内复制Hooks，释放锁后调用），网络请求等慢Hook会拖慢每一次日志调用；
+// AsyncHook的Fire只把日志的副本放入有界队列，由后台goroutine调用被包装的Hook
+type AsyncHook struct {
+	hook     logrus.Hook
+	timeout  time.Duration
+	overflow string
+	OnError  func(error) // Fire失败或超时时的回调，默认输出到stderr，不能再写入同一个logger
+
+	queue   chan *logrus.Entry
+	pending atomic.Int64 // 已入队还没有处理完的条数
+	closed  atomic.Bool
+	mu      sync.RWMutex // 保护closed之后不再写入queue
+	wg      sync.WaitGroup
+
+	fired, failed, timedOut, dropped atomic.Uint64
+}
+
+// NewAsyncHook 包装Hook并启动后台goroutine
+func NewAsyncHook(hook logrus.Hook, opts AsyncOptions) (*AsyncHook, error) {
+	switch opts.Overflow {
+	case "":
+		opts.Overflow = OverflowDropNewest
+	case OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+	default:
+		return nil, fmt.Errorf("unknown overflow policy %q", opts.Overflow)
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultAsyncQueueSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultAsyncTimeout
+	}
+
+	h := &AsyncHook{
+		hook:     hook,
+		timeout:  opts.Timeout,
+		overflow: opts.Overflow,
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+		},
+		queue: make(chan *logrus.Entry, opts.QueueSize),
+	}
+	h.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go h.run()
+	}
+	return h, nil
+}
+
+// Levels 与被包装的Hook相同
+func (h *AsyncHook) Levels() []logrus.Level {
+	return h.hook.Levels()
+}
+
+// Fire 把日志的副本放入队列，logrus会在Fire返回后继续使用entry
+func (h *AsyncHook) Fire(entry *logrus.Entry) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed.Load() {
+		return nil
+	}
+
+	e := entry.Dup()
+	e.Level = entry.Level
+	e.Message = entry.Message
+	e.Caller = entry.Caller
+
+	h.pending.Add(1)
+	switch h.overflow {
+	case OverflowBlock:
+		h.queue <- e
+	case OverflowDropOldest:
+		for {
+			select {
+			case h.queue <- e:
+				return nil
+			default:
+			}
+			select {
+			case <-h.queue:
+				h.pending.Add(-1)
+				h.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case h.queue <- e:
+		default:
+			h.pending.Add(-1)
+			h.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// run 后台goroutine，逐条调用被包装的Hook
+func (h *AsyncHook) run() {
+	defer h.wg.Done()
+	for e := range h.queue {
+		h.fire(e)
+		h.pending.Add(-1)
+	}
+}
+
+// fire 在当前goroutine中调用被包装的Hook，entry.Context带上超时
+// 不另起goroutine，同时调用Fire的数量不超过Workers，Flush和Close会等到Fire返回；
+// 被包装的Hook需要通过Context及时返回（如发送HTTP请求），返回时已经超时的计入TimedOut
+func (h *AsyncHook) fire(e *logrus.Entry) {
+	parent := e.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, h.timeout)
+	defer cancel()
+	e.Context = ctx
+
+	err := h.hook.Fire(e)
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		h.timedOut.Add(1)
+		if err == nil {
+			err = ctx.Err()
+		}
+		h.OnError(fmt.Errorf("hook timed out after %s: %w", h.timeout, err))
+	case err != nil:
+		h.failed.Add(1)
+		h.OnError(err)
+	default:
+		h.fired.Add(1)
+	}
+}
+
+// Flush 等待队列中的日志处理完成，被包装的Hook也有Flush时接着调用，用于退出前
+func (h *AsyncHook) Flush(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for h.pending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("flush async hook: %d entries pending: %w", h.pending.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	if f, ok := h.hook.(interface{ Flush(context.Context) error }); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Close 处理完队列中的日志后停止后台goroutine，之后的日志直接忽略
+func (h *AsyncHook) Close(ctx context.Context) error {
+	err := h.Flush(ctx)
+
+	h.mu.Lock()
+	if !h.closed.Swap(true) {
+		close(h.queue)
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = errors.Join(err, ctx.Err())
+	}
+	return err
+}
+
+// Stats 返回统计信息
+func (h *AsyncHook) Stats() AsyncStats {
+	return AsyncStats{
+		Fired:    h.fired.Load(),
+		Failed:   h.failed.Load(),
+		TimedOut: h.timedOut.Load(),
+		Dropped:  h.dropped.Load(),
+		Queued:   len(h.queue),
+	}
+}
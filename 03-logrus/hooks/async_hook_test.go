@@ -0,0 +1,286 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// gateHook 记录收到的消息，gate关闭前阻塞在Fire中，entry.Context取消时返回
+type gateHook struct {
+	gate      chan struct{}
+	started   chan string // Fire开始时发送消息，用于等待后台goroutine取走日志
+	ignoreCtx bool        // 不响应entry.Context的取消，模拟卡住的Hook
+
+	mu      sync.Mutex
+	msgs    []string
+	running int // 正在执行的Fire数
+	maxRun  int // 同时执行的Fire数的最大值
+	flushed bool
+}
+
+func newGateHook() *gateHook {
+	return &gateHook{gate: make(chan struct{}), started: make(chan string, 16)}
+}
+
+func (h *gateHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h *gateHook) Fire(e *logrus.Entry) error {
+	h.mu.Lock()
+	h.running++
+	h.maxRun = max(h.maxRun, h.running)
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.running--
+		h.mu.Unlock()
+	}()
+
+	h.started <- e.Message
+	done := e.Context.Done()
+	if h.ignoreCtx {
+		done = nil
+	}
+	select {
+	case <-h.gate:
+	case <-done:
+		return e.Context.Err()
+	}
+
+	h.mu.Lock()
+	h.msgs = append(h.msgs, e.Message)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *gateHook) Flush(context.Context) error {
+	h.mu.Lock()
+	h.flushed = true
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *gateHook) received() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.msgs...)
+}
+
+// fireMsg 通过AsyncHook发送一条Info日志
+func fireMsg(t *testing.T, h *AsyncHook, msg string) {
+	t.Helper()
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.InfoLevel
+	entry.Message = msg
+	if err := h.Fire(entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitStarted 等待后台goroutine开始处理msg
+func waitStarted(t *testing.T, hook *gateHook, msg string) {
+	t.Helper()
+	select {
+	case got := <-hook.started:
+		if got != msg {
+			t.Fatalf("started %q, want %q", got, msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %q to be fired", msg)
+	}
+}
+
+func TestAsyncHookOverflow(t *testing.T) {
+	tests := []struct {
+		overflow string
+		want     []string
+		dropped  uint64
+	}{
+		{OverflowDropNewest, []string{"0", "1", "2"}, 1},
+		{OverflowDropOldest, []string{"0", "2", "3"}, 1},
+		{OverflowBlock, []string{"0", "1", "2", "3"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.overflow, func(t *testing.T) {
+			hook := newGateHook()
+			h, err := NewAsyncHook(hook, AsyncOptions{QueueSize: 2, Overflow: tt.overflow, Timeout: time.Minute})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer h.Close(context.Background())
+
+			// 后台goroutine阻塞在第一条上，队列中是第二、三条
+			fireMsg(t, h, "0")
+			waitStarted(t, hook, "0")
+			fireMsg(t, h, "1")
+			fireMsg(t, h, "2")
+
+			fired := make(chan struct{})
+			go func() {
+				fireMsg(t, h, "3")
+				close(fired)
+			}()
+			if tt.overflow == OverflowBlock {
+				select {
+				case <-fired:
+					t.Fatal("Fire did not block on a full queue")
+				case <-time.After(50 * time.Millisecond):
+				}
+			} else {
+				<-fired
+			}
+			close(hook.gate)
+			<-fired
+
+			if err := h.Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got := hook.received(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("received %q, want %q", got, tt.want)
+			}
+			if got := h.Stats(); got.Dropped != tt.dropped || got.Fired != uint64(len(tt.want)) || got.Queued != 0 {
+				t.Fatalf("Stats() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestAsyncHookTimeout(t *testing.T) {
+	hook := newGateHook()
+	h, err := NewAsyncHook(hook, AsyncOptions{Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var reported []error
+	h.OnError = func(err error) {
+		mu.Lock()
+		reported = append(reported, err)
+		mu.Unlock()
+	}
+
+	fireMsg(t, h, "slow")
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := h.Stats(); got.TimedOut != 1 || got.Fired != 0 || got.Failed != 0 {
+		t.Fatalf("Stats() = %+v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || !errors.Is(reported[0], context.DeadlineExceeded) || !strings.Contains(reported[0].Error(), "timed out") {
+		t.Fatalf("reported = %v", reported)
+	}
+}
+
+func TestAsyncHookStuckHook(t *testing.T) {
+	hook := newGateHook()
+	hook.ignoreCtx = true
+	h, err := NewAsyncHook(hook, AsyncOptions{Timeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.OnError = func(error) {}
+
+	fireMsg(t, h, "a")
+	fireMsg(t, h, "b")
+	waitStarted(t, hook, "a")
+
+	// 超时后不另起goroutine继续处理，Fire返回前Flush不能返回
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := h.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Flush() = %v, want deadline exceeded", err)
+	}
+	hook.mu.Lock()
+	maxRun := hook.maxRun
+	hook.mu.Unlock()
+	if maxRun != 1 {
+		t.Fatalf("%d concurrent Fire calls with 1 worker", maxRun)
+	}
+
+	close(hook.gate)
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// a返回时已经超时，b在gate打开后才开始
+	if got := h.Stats(); got.TimedOut != 1 || got.Fired != 1 {
+		t.Fatalf("Stats() = %+v, want 1 timed out and 1 fired", got)
+	}
+}
+
+func TestAsyncHookWorkers(t *testing.T) {
+	hook := newGateHook()
+	h, err := NewAsyncHook(hook, AsyncOptions{Workers: 2, Timeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		fireMsg(t, h, msg)
+	}
+	for i := 0; i < 2; i++ {
+		<-hook.started
+	}
+	// 两个worker都阻塞时，其余的日志留在队列中
+	time.Sleep(20 * time.Millisecond)
+	if got := h.Stats().Queued; got != 2 {
+		t.Fatalf("Queued = %d, want 2", got)
+	}
+
+	close(hook.gate)
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if hook.maxRun != 2 || len(hook.msgs) != 4 {
+		t.Fatalf("maxRun = %d, received %q", hook.maxRun, hook.msgs)
+	}
+}
+
+func TestAsyncHookFlushAndClose(t *testing.T) {
+	hook := newGateHook()
+	h, err := NewAsyncHook(hook, AsyncOptions{Timeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fireMsg(t, h, "a")
+	fireMsg(t, h, "b")
+	waitStarted(t, hook, "a")
+
+	// Fire还没有返回时Flush不能返回
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := h.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Flush() while firing = %v, want deadline exceeded", err)
+	}
+
+	close(hook.gate)
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	hook.mu.Lock()
+	flushed := hook.flushed
+	hook.mu.Unlock()
+	if got := hook.received(); !reflect.DeepEqual(got, []string{"a", "b"}) || !flushed {
+		t.Fatalf("received %q, flushed %v", got, flushed)
+	}
+
+	// Close前入队的日志都会处理，之后的直接忽略
+	fireMsg(t, h, "c")
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fireMsg(t, h, "d")
+	if got := hook.received(); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Fatalf("received %q after Close", got)
+	}
+}
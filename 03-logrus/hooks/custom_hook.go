@@ -1,24 +1,89 @@
 package hooks
 
 import (
+	"os"
+	"runtime/debug"
+	"sync"
+
 	"github.com/sirupsen/logrus"
 )
 
-// CustomHook 自定义Hook
+// CustomHook 为每条日志添加公共字段
+// 静态字段来自配置（如 app_name、environment），动态字段每次通过回调获取（如 hostname、pid、commit）
+// 日志中已经有同名字段时不覆盖
 type CustomHook struct {
-	// 可以添加一些配置字段
+	Fields    logrus.Fields        // 静态字段
+	Dynamic   func() logrus.Fields // 动态字段，可以为nil
+	LogLevels []logrus.Level       // 处理的级别，为空处理全部级别
+}
+
+// NewCustomHook 创建添加公共字段的Hook
+//
+//	hooks.NewCustomHook(map[string]any{"app_name": "my_app"}, hooks.RuntimeFields)
+func NewCustomHook(fields map[string]any, dynamic func() logrus.Fields) *CustomHook {
+	return &CustomHook{Fields: logrus.Fields(fields), Dynamic: dynamic}
 }
 
 // Fire 实现Hook接口
 func (hook *CustomHook) Fire(entry *logrus.Entry) error {
 	// 添加自定义字段
-	entry.Data["app_name"] = "my_app"
-	entry.Data["environment"] = "production"
+	for k, v := range hook.Fields {
+		if _, ok := entry.Data[k]; !ok {
+			entry.Data[k] = v
+		}
+	}
+	if hook.Dynamic != nil {
+		for k, v := range hook.Dynamic() {
+			if _, ok := entry.Data[k]; !ok {
+				entry.Data[k] = v
+			}
+		}
+	}
 
 	return nil
 }
 
 // Levels 定义Hook处理的日志级别
 func (hook *CustomHook) Levels() []logrus.Level {
-	return logrus.AllLevels
+	if len(hook.LogLevels) == 0 {
+		return logrus.AllLevels
+	}
+	return hook.LogLevels
+}
+
+var (
+	runtimeOnce   sync.Once
+	runtimeFields logrus.Fields
+)
+
+// RuntimeFields 返回进程相关的字段：hostname、pid，以及构建时记录的git commit
+// 进程运行期间不会变化，只在第一次调用时获取
+func RuntimeFields() logrus.Fields {
+	runtimeOnce.Do(func() {
+		runtimeFields = logrus.Fields{"pid": os.Getpid()}
+		if hostname, err := os.Hostname(); err == nil {
+			runtimeFields["hostname"] = hostname
+		}
+		if commit := vcsRevision(); commit != "" {
+			runtimeFields["commit"] = commit
+		}
+	})
+	return runtimeFields
+}
+
+// vcsRevision 读取go build记录的vcs.revision，只取前12位
+func vcsRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			if len(s.Value) > 12 {
+				return s.Value[:12]
+			}
+			return s.Value
+		}
+	}
+	return ""
 }
@@ -0,0 +1,301 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HookOptions 按名称配置Hook
+type HookOptions struct {
+	Enabled *bool          `yaml:"enabled"` // 是否启用，为空时保持注册时的状态（默认启用）
+	Levels  []string       `yaml:"levels"`  // 只处理这些级别，同时受Hook自身Levels()的限制，为空不限制
+	Fields  map[string]any `yaml:"fields"`  // Hook自定义的字段配置，如CustomHook的静态字段
+	Async   *AsyncOptions  `yaml:"async"`   // 通过AsyncHook异步调用，只在注册时生效，为空则同步调用
+}
+
+// Registry 按名称管理Hook，本身作为一个Hook添加到logger上
+// logrus的Hooks不能在运行时安全地修改，通过Registry可以随时启用、禁用Hook和调整级别
+type Registry struct {
+	// OnError Hook返回错误时的回调，默认输出到stderr，不能再写入同一个logger
+	// Fire本身不返回错误：logrus遇到第一个返回错误的Hook就不再调用后面的Hook
+	OnError func(error)
+
+	mu      sync.RWMutex
+	hooks   []*registered
+	options map[string]HookOptions // 先于Register加载的配置
+}
+
+type registered struct {
+	name    string
+	hook    logrus.Hook
+	enabled bool
+	levels  map[logrus.Level]struct{} // nil 不限制
+}
+
+// NewRegistry 创建Hook注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+		},
+		options: make(map[string]HookOptions),
+	}
+}
+
+// releaseTimeout 替换Hook时关闭原来的Hook最多等待的时间
+const releaseTimeout = 5 * time.Second
+
+// Register 注册Hook，已经加载的同名配置会立即生效
+// 同名Hook会被替换，原来的Hook有Close或Flush方法时（如AsyncHook）在替换后关闭或刷新，
+// 避免后台goroutine和队列中的日志泄漏
+func (r *Registry) Register(name string, hook logrus.Hook) error {
+	h := &registered{name: name, hook: hook, enabled: true}
+
+	r.mu.Lock()
+	if opts, ok := r.options[name]; ok {
+		if err := h.apply(opts); err != nil {
+			r.mu.Unlock()
+			return fmt.Errorf("hook %q: %w", name, err)
+		}
+		if opts.Async != nil {
+			async, err := NewAsyncHook(hook, *opts.Async)
+			if err != nil {
+				r.mu.Unlock()
+				return fmt.Errorf("hook %q: %w", name, err)
+			}
+			h.hook = async
+		}
+	}
+
+	var replaced logrus.Hook
+	if old := r.find(name); old != nil {
+		replaced = old.hook
+		*old = *h
+	} else {
+		r.hooks = append(r.hooks, h)
+	}
+	r.mu.Unlock()
+
+	// 在锁外关闭，等待队列处理完成时不影响其它日志
+	if replaced != nil && replaced != h.hook {
+		r.release(name, replaced)
+	}
+	return nil
+}
+
+// release 关闭被替换的Hook，错误通过OnError报告
+func (r *Registry) release(name string, hook logrus.Hook) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	var err error
+	switch h := hook.(type) {
+	case interface{ Close(context.Context) error }:
+		err = h.Close(ctx)
+	case interface{ Flush(context.Context) error }:
+		err = h.Flush(ctx)
+	}
+	if err != nil && r.OnError != nil {
+		r.OnError(fmt.Errorf("hook %q: close replaced hook: %w", name, err))
+	}
+}
+
+// Configure 按名称应用配置，还没有注册的Hook在注册时生效
+func (r *Registry) Configure(options map[string]HookOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, opts := range options {
+		if _, err := ParseLevels(opts.Levels); err != nil {
+			return fmt.Errorf("hook %q: %w", name, err)
+		}
+		r.options[name] = opts
+		if h := r.find(name); h != nil {
+			h.apply(opts)
+		}
+	}
+	return nil
+}
+
+// Enable 启用Hook
+func (r *Registry) Enable(name string) error {
+	return r.update(name, func(h *registered) { h.enabled = true })
+}
+
+// Disable 禁用Hook
+func (r *Registry) Disable(name string) error {
+	return r.update(name, func(h *registered) { h.enabled = false })
+}
+
+// SetLevels 限制Hook处理的级别，为空不限制
+func (r *Registry) SetLevels(name string, levels ...logrus.Level) error {
+	return r.update(name, func(h *registered) { h.levels = levelSet(levels) })
+}
+
+// Names 返回已注册的Hook名称和是否启用
+func (r *Registry) Names() map[string]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make(map[string]bool, len(r.hooks))
+	for _, h := range r.hooks {
+		names[h.name] = h.enabled
+	}
+	return names
+}
+
+// Levels 注册表本身处理全部级别，由每个Hook分别判断
+func (r *Registry) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 按注册顺序调用启用且级别匹配的Hook，错误通过OnError报告，始终返回nil
+func (r *Registry) Fire(entry *logrus.Entry) error {
+	r.fire(entry, nil)
+	return nil
+}
+
+// Select 返回只调用名称匹配的Hook的视图，启用状态和级别仍由注册表管理
+// 用于把修改日志的Hook（如CustomHook）放在输出之前，发送网络请求的Hook放在输出之后
+func (r *Registry) Select(match func(name string) bool) logrus.Hook {
+	return &registryView{registry: r, match: match}
+}
+
+// fire 调用match匹配的Hook，match为空时调用全部
+func (r *Registry) fire(entry *logrus.Entry, match func(string) bool) {
+	// 被采样丢弃的日志不再告警、发送到收集端
+	if Dropped(entry) {
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, h := range r.hooks {
+		if match != nil && !match(h.name) {
+			continue
+		}
+		if !h.fires(entry.Level) {
+			continue
+		}
+		if err := h.hook.Fire(entry); err != nil && r.OnError != nil {
+			r.OnError(fmt.Errorf("hook %q: %w", h.name, err))
+		}
+	}
+}
+
+// registryView Registry.Select返回的视图
+type registryView struct {
+	registry *Registry
+	match    func(string) bool
+}
+
+// Levels 处理全部级别，由每个Hook分别判断
+func (v *registryView) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 调用匹配的Hook，始终返回nil
+func (v *registryView) Fire(entry *logrus.Entry) error {
+	v.registry.fire(entry, v.match)
+	return nil
+}
+
+// Flush 等待所有异步Hook处理完队列中的日志，用于退出前
+func (r *Registry) Flush(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var errs []error
+	for _, h := range r.hooks {
+		if f, ok := h.hook.(interface{ Flush(context.Context) error }); ok {
+			if err := f.Flush(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("hook %q: %w", h.name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Registry) update(name string, fn func(h *registered)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.find(name)
+	if h == nil {
+		return fmt.Errorf("hook %q is not registered", name)
+	}
+	fn(h)
+	return nil
+}
+
+func (r *Registry) find(name string) *registered {
+	for _, h := range r.hooks {
+		if h.name == name {
+			return h
+		}
+	}
+	return nil
+}
+
+// apply 应用启用状态和级别配置
+func (h *registered) apply(opts HookOptions) error {
+	levels, err := ParseLevels(opts.Levels)
+	if err != nil {
+		return err
+	}
+	if opts.Enabled != nil {
+		h.enabled = *opts.Enabled
+	}
+	h.levels = levelSet(levels)
+	return nil
+}
+
+// levelSet 为空时返回nil，表示不限制
+func levelSet(levels []logrus.Level) map[logrus.Level]struct{} {
+	if len(levels) == 0 {
+		return nil
+	}
+	set := make(map[logrus.Level]struct{}, len(levels))
+	for _, l := range levels {
+		set[l] = struct{}{}
+	}
+	return set
+}
+
+// fires 判断Hook是否处理该级别
+func (h *registered) fires(level logrus.Level) bool {
+	if !h.enabled {
+		return false
+	}
+	if h.levels != nil {
+		if _, ok := h.levels[level]; !ok {
+			return false
+		}
+	}
+	for _, l := range h.hook.Levels() {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLevels 按名称解析级别，如 ["info", "error"]
+func ParseLevels(names []string) ([]logrus.Level, error) {
+	levels := make([]logrus.Level, 0, len(names))
+	for _, name := range names {
+		l, err := logrus.ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	return levels, nil
+}
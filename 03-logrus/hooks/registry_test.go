@@ -0,0 +1,253 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// recordHook 记录收到的消息
+type recordHook struct {
+	levels []logrus.Level
+	err    error
+
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (h *recordHook) Levels() []logrus.Level {
+	if h.levels == nil {
+		return logrus.AllLevels
+	}
+	return h.levels
+}
+
+func (h *recordHook) Fire(e *logrus.Entry) error {
+	h.mu.Lock()
+	h.msgs = append(h.msgs, e.Message)
+	h.mu.Unlock()
+	return h.err
+}
+
+func (h *recordHook) received() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.msgs...)
+}
+
+// newRegistryLogger 创建只通过注册表调用Hook的logger
+func newRegistryLogger(r *Registry) *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.AddHook(r)
+	return logger
+}
+
+func TestRegistryLevelsAndEnable(t *testing.T) {
+	r := NewRegistry()
+	logger := newRegistryLogger(r)
+
+	all := &recordHook{}
+	errorsOnly := &recordHook{levels: []logrus.Level{logrus.ErrorLevel}}
+	if err := r.Register("all", all); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("errors", errorsOnly); err != nil {
+		t.Fatal(err)
+	}
+	// 注册表的级别和Hook自身的Levels()同时生效
+	if err := r.SetLevels("errors", logrus.WarnLevel, logrus.ErrorLevel); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetLevels("all", logrus.InfoLevel, logrus.ErrorLevel); err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	if got := all.received(); !reflect.DeepEqual(got, []string{"info", "error"}) {
+		t.Errorf("all received %q", got)
+	}
+	if got := errorsOnly.received(); !reflect.DeepEqual(got, []string{"error"}) {
+		t.Errorf("errors received %q", got)
+	}
+
+	if err := r.Disable("all"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Error("while disabled")
+	if err := r.Enable("all"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Error("enabled again")
+	if got := all.received(); !reflect.DeepEqual(got, []string{"info", "error", "enabled again"}) {
+		t.Errorf("all received %q", got)
+	}
+	if got := r.Names(); !reflect.DeepEqual(got, map[string]bool{"all": true, "errors": true}) {
+		t.Errorf("Names() = %v", got)
+	}
+
+	if err := r.Disable("missing"); err == nil {
+		t.Error("Disable(missing): want error")
+	}
+}
+
+func TestRegistryConfigure(t *testing.T) {
+	r := NewRegistry()
+	logger := newRegistryLogger(r)
+	disabled := false
+
+	// 先加载配置，注册时生效
+	err := r.Configure(map[string]HookOptions{
+		"audit": {Levels: []string{"error"}},
+		"debug": {Enabled: &disabled},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit, debug := &recordHook{}, &recordHook{}
+	r.Register("audit", audit)
+	r.Register("debug", debug)
+
+	logger.Info("info")
+	logger.Error("error")
+	if got := audit.received(); !reflect.DeepEqual(got, []string{"error"}) {
+		t.Errorf("audit received %q", got)
+	}
+	if got := debug.received(); len(got) != 0 {
+		t.Errorf("disabled hook received %q", got)
+	}
+
+	// 已经注册的Hook立即生效
+	if err := r.Configure(map[string]HookOptions{"audit": {Levels: []string{"info"}}}); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("info 2")
+	logger.Error("error 2")
+	if got := audit.received(); !reflect.DeepEqual(got, []string{"error", "info 2"}) {
+		t.Errorf("audit received %q", got)
+	}
+
+	if err := r.Configure(map[string]HookOptions{"audit": {Levels: []string{"loud"}}}); err == nil {
+		t.Error("Configure with unknown level: want error")
+	}
+}
+
+func TestRegistrySelect(t *testing.T) {
+	r := NewRegistry()
+	first, second := &recordHook{}, &recordHook{}
+	r.Register("first", first)
+	r.Register("second", second)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(r.Select(func(name string) bool { return name == "first" }))
+
+	logger.Info("selected")
+	if got := first.received(); !reflect.DeepEqual(got, []string{"selected"}) {
+		t.Errorf("first received %q", got)
+	}
+	if got := second.received(); len(got) != 0 {
+		t.Errorf("second received %q", got)
+	}
+
+	// 视图使用注册表的启用状态
+	r.Disable("first")
+	logger.Info("disabled")
+	if got := first.received(); len(got) != 1 {
+		t.Errorf("first received %q after Disable", got)
+	}
+}
+
+func TestRegistryReportsErrors(t *testing.T) {
+	r := NewRegistry()
+	var reported []error
+	r.OnError = func(err error) { reported = append(reported, err) }
+	failing, next := &recordHook{err: errors.New("boom")}, &recordHook{}
+	r.Register("failing", failing)
+	r.Register("next", next)
+
+	newRegistryLogger(r).Info("hello")
+
+	// 失败的Hook不影响后面的Hook
+	if got := next.received(); !reflect.DeepEqual(got, []string{"hello"}) {
+		t.Errorf("next received %q", got)
+	}
+	if len(reported) != 1 || reported[0].Error() != `hook "failing": boom` {
+		t.Errorf("reported = %v", reported)
+	}
+}
+
+func TestRegistryReplaceClosesAsyncHook(t *testing.T) {
+	r := NewRegistry()
+	logger := newRegistryLogger(r)
+
+	gate := newGateHook()
+	old, err := NewAsyncHook(gate, AsyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Register("ship", old)
+	logger.Info("queued")
+	waitStarted(t, gate, "queued")
+
+	replaced := make(chan struct{})
+	go func() {
+		r.Register("ship", &recordHook{})
+		close(replaced)
+	}()
+	// 原来的AsyncHook处理完队列中的日志后关闭
+	close(gate.gate)
+	<-replaced
+
+	if got := gate.received(); !reflect.DeepEqual(got, []string{"queued"}) {
+		t.Fatalf("old hook received %q", got)
+	}
+	logger.Info("after replace")
+	fireMsg(t, old, "after close")
+	old.Flush(context.Background())
+	if got := gate.received(); len(got) != 1 {
+		t.Fatalf("old hook received %q after it was replaced", got)
+	}
+	if got := r.Names(); len(got) != 1 {
+		t.Fatalf("Names() = %v", got)
+	}
+}
+
+func TestCustomHook(t *testing.T) {
+	hook := NewCustomHook(map[string]any{"app_name": "my_app", "env": "dev"}, func() logrus.Fields {
+		return logrus.Fields{"request_count": 3, "env": "ignored"}
+	})
+
+	entry := logrus.NewEntry(logrus.New()).WithField("env", "prod")
+	if err := hook.Fire(entry); err != nil {
+		t.Fatal(err)
+	}
+	want := logrus.Fields{"app_name": "my_app", "env": "prod", "request_count": 3}
+	if !reflect.DeepEqual(entry.Data, want) {
+		t.Errorf("Data = %v, want %v", entry.Data, want)
+	}
+	if got := hook.Levels(); len(got) != len(logrus.AllLevels) {
+		t.Errorf("Levels() = %v", got)
+	}
+}
+
+func TestRuntimeFields(t *testing.T) {
+	fields := RuntimeFields()
+	if fields["pid"] != os.Getpid() {
+		t.Errorf("pid = %v", fields["pid"])
+	}
+	if hostname, err := os.Hostname(); err == nil && fields["hostname"] != hostname {
+		t.Errorf("hostname = %v, want %s", fields["hostname"], hostname)
+	}
+}
@@ -0,0 +1,81 @@
+package hooks
+
+import (
+	"01-zap/sampling"
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SamplingHook 对高频日志采样和按字段值限流，与01-zap共用sampling包
+// Hook无法阻止logrus本身的输出，被丢弃的日志只在ctx中做标记，WriterHook和Registry会跳过这些日志，
+// 所以需要作为第一个Hook添加，并且输出全部通过WriterHook完成
+type SamplingHook struct {
+	sampler *sampling.Sampler
+	field   string
+}
+
+type sampleKey struct{}
+
+// 标记在entry.Context中的采样结果
+const (
+	sampleDropped = iota + 1
+	sampleSummary // 汇总日志，不再采样
+)
+
+// NewSamplingHook 创建采样Hook，被丢弃的条数定期以 "suppressed N similar entries" 写入logger
+// 退出前需要Close，输出最后一次汇总
+func NewSamplingHook(logger *logrus.Logger, opts sampling.Options) *SamplingHook {
+	report := func(sup sampling.Suppressed) {
+		level, err := logrus.ParseLevel(sup.Level)
+		if err != nil {
+			return
+		}
+		ctx := context.WithValue(context.Background(), sampleKey{}, sampleSummary)
+		logger.WithContext(ctx).WithFields(logrus.Fields{
+			sampling.MessageKey:    sup.Message,
+			sampling.SuppressedKey: sup.Count,
+		}).Log(level, sampling.SummaryMessage(sup.Count))
+	}
+	return &SamplingHook{sampler: sampling.New(opts, report), field: opts.LimitField}
+}
+
+func (hook *SamplingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 先按消息采样，再按LimitField的值限流
+func (hook *SamplingHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Value(sampleKey{}) == sampleSummary {
+		// 汇总日志由后台goroutine输出，调用者没有意义
+		entry.Caller = nil
+		return nil
+	}
+
+	level := entry.Level.String()
+	keep := hook.sampler.Sample(level, entry.Message)
+	if keep && hook.field != "" {
+		if v, ok := entry.Data[hook.field]; ok {
+			keep = hook.sampler.Limit(level, entry.Message, fmt.Sprint(v))
+		}
+	}
+	if !keep {
+		entry.Context = context.WithValue(ctx, sampleKey{}, sampleDropped)
+	}
+	return nil
+}
+
+// Close 停止定期汇总，并输出剩余的被丢弃条数
+func (hook *SamplingHook) Close() error {
+	return hook.sampler.Close()
+}
+
+// Dropped 日志是否被SamplingHook丢弃
+func Dropped(entry *logrus.Entry) bool {
+	return entry.Context != nil && entry.Context.Value(sampleKey{}) == sampleDropped
+}
@@ -0,0 +1,75 @@
+package hooks
+
+import (
+	"01-zap/sampling"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSamplingHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetReportCaller(true)
+
+	sampler := NewSamplingHook(logger, sampling.Options{
+		Tick:            time.Hour,
+		Initial:         2,
+		LimitField:      "userID",
+		Rate:            1,
+		SummaryInterval: time.Hour,
+	})
+	logger.AddHook(sampler)
+	logger.AddHook(NewWriterHook(&buf, &logrus.JSONFormatter{}))
+
+	for i := 0; i < 10; i++ {
+		logger.WithField("iteration", i).Info("performance test")
+	}
+	for i := 0; i < 3; i++ {
+		logger.WithField("userID", "u1").Warn("处理用户请求")
+	}
+	if err := sampler.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+
+	count := map[string]int{}
+	for _, m := range lines {
+		count[m["msg"].(string)]++
+	}
+	// performance test 前2条；处理用户请求 前2条通过采样，令牌桶只允许1条
+	if count["performance test"] != 2 || count["处理用户请求"] != 1 {
+		t.Errorf("unexpected counts %v", count)
+	}
+
+	summaries := map[string]map[string]any{}
+	for _, m := range lines {
+		if msg, ok := m[sampling.MessageKey].(string); ok {
+			summaries[msg] = m
+		}
+	}
+	perf := summaries["performance test"]
+	if perf == nil || perf["msg"] != sampling.SummaryMessage(8) || perf[sampling.SuppressedKey] != float64(8) || perf["level"] != "info" {
+		t.Errorf("unexpected performance test summary %v", perf)
+	}
+	if _, ok := perf["file"]; ok {
+		t.Errorf("summary should not report caller: %v", perf)
+	}
+	if req := summaries["处理用户请求"]; req == nil || req[sampling.SuppressedKey] != float64(2) || req["level"] != "warning" {
+		t.Errorf("unexpected 处理用户请求 summary %v", req)
+	}
+}
@@ -0,0 +1,52 @@
+package hooks
+
+import (
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WriterHook 使用自己的格式把日志写入Writer
+// logrus只有一个Formatter和一个Output，需要控制台输出文本、文件输出JSON时，
+// 为每个输出目标添加一个WriterHook，并把logger本身的输出设置为io.Discard
+type WriterHook struct {
+	Writer    io.Writer
+	Formatter logrus.Formatter
+	LogLevels []logrus.Level           // 处理的级别，为空处理全部级别
+	Filter    func(*logrus.Entry) bool // 返回false时不写入，为空写入全部
+
+	mu sync.Mutex // logrus调用Hook时不持有锁，多个goroutine写同一个Writer需要加锁
+}
+
+// NewWriterHook 创建输出Hook
+func NewWriterHook(w io.Writer, formatter logrus.Formatter, levels ...logrus.Level) *WriterHook {
+	return &WriterHook{Writer: w, Formatter: formatter, LogLevels: levels}
+}
+
+// Fire 格式化后写入，跳过被SamplingHook丢弃的日志
+func (hook *WriterHook) Fire(entry *logrus.Entry) error {
+	if Dropped(entry) {
+		return nil
+	}
+	if hook.Filter != nil && !hook.Filter(entry) {
+		return nil
+	}
+	b, err := hook.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	_, err = hook.Writer.Write(b)
+	return err
+}
+
+// Levels 定义Hook处理的日志级别
+func (hook *WriterHook) Levels() []logrus.Level {
+	if len(hook.LogLevels) == 0 {
+		return logrus.AllLevels
+	}
+	return hook.LogLevels
+}
@@ -0,0 +1,89 @@
+// Package logrusx 基于logrus的logx.Logger实现
+//
+//	config.InitLogger()
+//	logger := logrusx.New(config.Log)
+package logrusx
+
+import (
+	"01-zap/logx"
+	"context"
+	"runtime"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logger 基于*logrus.Entry的实现，With通过WithFields追加字段
+type logger struct {
+	entry *logrus.Entry
+}
+
+// New 把*logrus.Logger包装为logx.Logger
+// logrus无法跳过调用层级，需要先添加CallerHook，调用者信息才会指向调用logx的代码
+func New(l *logrus.Logger) logx.Logger {
+	return &logger{entry: logrus.NewEntry(l)}
+}
+
+func (l *logger) Debug(ctx context.Context, msg string, fields ...logx.Field) {
+	l.log(ctx, logrus.DebugLevel, msg, fields)
+}
+
+func (l *logger) Info(ctx context.Context, msg string, fields ...logx.Field) {
+	l.log(ctx, logrus.InfoLevel, msg, fields)
+}
+
+func (l *logger) Warn(ctx context.Context, msg string, fields ...logx.Field) {
+	l.log(ctx, logrus.WarnLevel, msg, fields)
+}
+
+func (l *logger) Error(ctx context.Context, msg string, fields ...logx.Field) {
+	l.log(ctx, logrus.ErrorLevel, msg, fields)
+}
+
+func (l *logger) With(fields ...logx.Field) logx.Logger {
+	return &logger{entry: l.entry.WithFields(logrusFields(logx.Merge(nil, fields)))}
+}
+
+type callerKey struct{}
+
+// log 记录调用logx的PC，由CallerHook替换logrus找到的调用者
+func (l *logger) log(ctx context.Context, level logrus.Level, msg string, fields []logx.Field) {
+	if !l.entry.Logger.IsLevelEnabled(level) {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// 跳过runtime.Callers、log和Debug/Info等方法
+	var pcs [1]uintptr
+	if runtime.Callers(3, pcs[:]) > 0 {
+		ctx = context.WithValue(ctx, callerKey{}, pcs[0])
+	}
+	l.entry.WithContext(ctx).WithFields(logrusFields(logx.Merge(ctx, fields))).Log(level, msg)
+}
+
+func logrusFields(fields []logx.Field) logrus.Fields {
+	out := make(logrus.Fields, len(fields))
+	for _, f := range fields {
+		out[f.Key] = f.Value
+	}
+	return out
+}
+
+// CallerHook 把通过logx输出的日志的调用者替换为调用logx的代码
+// 需要在其它Hook之前添加，其它Hook和输出才能看到正确的调用者
+type CallerHook struct{}
+
+func (CallerHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (CallerHook) Fire(entry *logrus.Entry) error {
+	if entry.Caller == nil || entry.Context == nil {
+		return nil
+	}
+	if pc, ok := entry.Context.Value(callerKey{}).(uintptr); ok {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		entry.Caller = &frame
+	}
+	return nil
+}
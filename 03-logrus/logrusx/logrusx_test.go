@@ -0,0 +1,49 @@
+package logrusx
+
+import (
+	"01-zap/logx"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&buf)
+	l.SetLevel(logrus.InfoLevel)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	l.SetReportCaller(true)
+	l.AddHook(CallerHook{})
+
+	logger := New(l).With(logx.String("module", "user"))
+	ctx := logx.WithContext(context.Background(), logx.String("requestID", "req-1"))
+	logger.Debug(ctx, "ignored")
+	logger.Error(ctx, "create user failed", logx.Int("id", 7), logx.Err(errors.New("duplicate")))
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level":     "error",
+		"msg":       "create user failed",
+		"module":    "user",
+		"requestID": "req-1",
+		"id":        float64(7),
+		"error":     "duplicate",
+	}
+	for key, v := range want {
+		if got[key] != v {
+			t.Errorf("%s = %v, want %v", key, got[key], v)
+		}
+	}
+	if file, _ := got["file"].(string); !strings.Contains(file, "logrusx_test.go") {
+		t.Errorf("file = %v, want logrusx_test.go", got["file"])
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"03-logrus/config"
-	"03-logrus/hooks"
 	"fmt"
 
 	"time"
@@ -20,9 +19,10 @@ type User struct {
 func main() {
 	// 初始化日志
 	config.InitLogger()
+	defer config.Close()
 
-	// 添加自定义Hook
-	config.Log.AddHook(&hooks.CustomHook{})
+	// 添加公共字段的Hook已经按配置注册，可以按名称启用、禁用
+	// config.Hooks.Disable(config.CustomHookName)
 
 	// 1. 基本日志示例
 	config.Log.Info("server starting...")
@@ -63,6 +63,9 @@ func main() {
 
 	contextLogger.Info("处理用户请求")
 
+	// 带有audit字段的日志可以通过routes单独写入审计文件
+	contextLogger.WithField("audit", true).Info("用户信息已更新")
+
 	// 7. 性能记录示例
 	startTime := time.Now()
 	// ... 执行一些操作
@@ -9,7 +9,7 @@ import (
 // 中间件类型定义
 type Middleware func(http.HandlerFunc) http.HandlerFunc
 
-// 增强的中间件链接器
+// 增强的中间件链接器，按声明顺序执行，与 02-middleware/middleware.Stack 一致
 func chainMiddleware(middlewares ...Middleware) Middleware {
 	return func(final http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
@@ -32,3 +32,7 @@ require (
 	google.golang.org/protobuf v1.34.1 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+require 01-zap v0.0.0-00010101000000-000000000000
+
+replace 01-zap => ../01-zap
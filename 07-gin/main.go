@@ -1,9 +1,18 @@
 package main
 
 import (
+	"01-zap/audit"
+	"01-zap/rotate"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -21,7 +30,54 @@ var users = []User{
 	{ID: 2, Username: "user2", Password: "pass2", Age: 25},
 }
 
+// 审计日志，记录用户的创建、更新和删除
+var auditLog *audit.Writer
+
 func main() {
+	// 打开审计日志，与应用日志分开保存，按天轮转
+	// 检查点的HMAC密钥通过环境变量AUDIT_HMAC_KEY指定
+	key := os.Getenv("AUDIT_HMAC_KEY")
+	if key == "" {
+		log.Println("AUDIT_HMAC_KEY is not set, audit checkpoints will not be signed")
+	}
+	var err error
+	auditLog, err = audit.Open(audit.Options{
+		Rotate: rotate.Options{
+			Pattern:      "logs/audit/audit.log.%Y%m%d",
+			LinkName:     "logs/audit/audit.log",
+			RotationTime: 24 * time.Hour,
+			Compress:     true,
+		},
+		Key: []byte(key),
+	})
+	if err != nil {
+		log.Fatalf("open audit log: %v", err)
+	}
+	defer auditLog.Close()
+
+	r := newRouter(adminAccounts())
+
+	// 启动服务器，收到SIGINT/SIGTERM后停止接收请求，返回后关闭审计日志，写入最后的检查点
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+}
+
+// newRouter 创建路由，accounts是修改用户的接口允许的用户名和密码
+func newRouter(accounts gin.Accounts) *gin.Engine {
 	// 创建默认的 gin 引擎
 	r := gin.Default()
 
@@ -48,18 +104,37 @@ func main() {
 		api.GET("/users", getUsers)
 		api.GET("/users/:id", getUserByID)
 
+		// 修改用户的接口需要HTTP Basic认证，审计日志的操作者是认证的用户名
+		admin := api.Group("", gin.BasicAuth(accounts))
+
 		// POST 请求
-		api.POST("/users", createUser)
+		admin.POST("/users", createUser)
 
 		// PUT 请求
-		api.PUT("/users/:id", updateUser)
+		admin.PUT("/users/:id", updateUser)
 
 		// DELETE 请求
-		api.DELETE("/users/:id", deleteUser)
+		admin.DELETE("/users/:id", deleteUser)
 	}
 
-	// 启动服务器
-	r.Run(":8080")
+	return r
+}
+
+// adminAccounts 从环境变量ADMIN_ACCOUNTS读取账号，格式为 user1:pass1,user2:pass2
+// 未设置时使用演示账号 admin:admin
+func adminAccounts() gin.Accounts {
+	accounts := gin.Accounts{}
+	for _, pair := range strings.Split(os.Getenv("ADMIN_ACCOUNTS"), ",") {
+		user, pass, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && user != "" {
+			accounts[user] = pass
+		}
+	}
+	if len(accounts) == 0 {
+		log.Println("ADMIN_ACCOUNTS is not set, using the demo account admin:admin")
+		accounts["admin"] = "admin"
+	}
+	return accounts
 }
 
 // 中间件：日志记录
@@ -86,6 +161,32 @@ func Logger() gin.HandlerFunc {
 	}
 }
 
+// auditUser 记录用户操作，不记录密码
+// 操作者只取认证中间件（如gin.BasicAuth）保存的用户名，客户端可以随意设置的X-User请求头只作为数据记录
+func auditUser(c *gin.Context, action string, user User) {
+	actor := "unauthenticated"
+	if name := c.GetString(gin.AuthUserKey); name != "" {
+		actor = name
+	}
+	data := map[string]any{
+		"username": user.Username,
+		"age":      user.Age,
+		"ip":       c.ClientIP(),
+	}
+	if claimed := c.GetHeader("X-User"); claimed != "" {
+		data["claimed_user"] = claimed
+	}
+	err := auditLog.Log(audit.Event{
+		Actor:    actor,
+		Action:   action,
+		Resource: fmt.Sprintf("user:%d", user.ID),
+		Data:     data,
+	})
+	if err != nil {
+		fmt.Fprintf(gin.DefaultErrorWriter, "write audit log: %v\n", err)
+	}
+}
+
 // 处理函数：获取所有用户
 func getUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -115,6 +216,7 @@ func createUser(c *gin.Context) {
 
 	newUser.ID = len(users) + 1
 	users = append(users, newUser)
+	auditUser(c, "user.create", newUser)
 
 	c.JSON(http.StatusCreated, newUser)
 }
@@ -133,6 +235,7 @@ func updateUser(c *gin.Context) {
 		if fmt.Sprint(user.ID) == id {
 			updateUser.ID = user.ID
 			users[i] = updateUser
+			auditUser(c, "user.update", updateUser)
 			c.JSON(http.StatusOK, updateUser)
 			return
 		}
@@ -146,6 +249,7 @@ func deleteUser(c *gin.Context) {
 	for i, user := range users {
 		if fmt.Sprint(user.ID) == id {
 			users = append(users[:i], users[i+1:]...)
+			auditUser(c, "user.delete", user)
 			c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
 			return
 		}
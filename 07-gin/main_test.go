@@ -0,0 +1,112 @@
+package main
+
+import (
+	"01-zap/audit"
+	"01-zap/rotate"
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openTestAuditLog 把审计日志写到临时目录，返回轮转文件的Pattern
+func openTestAuditLog(t *testing.T) string {
+	t.Helper()
+	pattern := filepath.Join(t.TempDir(), "audit.log.%Y%m%d")
+	var err error
+	auditLog, err = audit.Open(audit.Options{Rotate: rotate.Options{Pattern: pattern}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pattern
+}
+
+// auditEvents 读取审计日志中的事件记录
+func auditEvents(t *testing.T, pattern string) []audit.Record {
+	t.Helper()
+	files, err := rotate.Files(pattern, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []audit.Record
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var rec audit.Record
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Type == "event" {
+				events = append(events, rec)
+			}
+		}
+		f.Close()
+	}
+	return events
+}
+
+func TestCreateUserAuditActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pattern := openTestAuditLog(t)
+	r := newRouter(gin.Accounts{"alice": "secret"})
+
+	body := `{"username":"carol","password":"pass3","age":30}`
+
+	// 没有认证的请求不能修改用户
+	req := httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated POST: status = %d, want 401", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", "mallory")
+	req.SetBasicAuth("alice", "secret")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST: status = %d, body %s", w.Code, w.Body)
+	}
+
+	if err := auditLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+	events := auditEvents(t, pattern)
+	if len(events) != 1 {
+		t.Fatalf("got %d audit events, want 1", len(events))
+	}
+	// 操作者是认证的用户名，X-User请求头只作为数据记录
+	ev := events[0]
+	if ev.Actor != "alice" || ev.Action != "user.create" || ev.Data["claimed_user"] != "mallory" {
+		t.Fatalf("audit event = %+v", ev)
+	}
+	if _, ok := ev.Data["password"]; ok {
+		t.Fatal("audit event contains the password")
+	}
+}
+
+func TestAdminAccounts(t *testing.T) {
+	t.Setenv("ADMIN_ACCOUNTS", "alice:secret, bob:hunter2,invalid")
+	got := adminAccounts()
+	if len(got) != 2 || got["alice"] != "secret" || got["bob"] != "hunter2" {
+		t.Fatalf("adminAccounts() = %v", got)
+	}
+
+	t.Setenv("ADMIN_ACCOUNTS", "")
+	if got := adminAccounts(); len(got) != 1 || got["admin"] != "admin" {
+		t.Fatalf("adminAccounts() without ADMIN_ACCOUNTS = %v", got)
+	}
+}
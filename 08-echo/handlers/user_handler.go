@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"01-zap/logx"
 	"08-echo/models"
 	"08-echo/services"
 	"github.com/labstack/echo/v4"
@@ -10,16 +11,19 @@ import (
 
 type UserHandler struct {
 	userService *services.UserService
+	logger      logx.Logger
 }
 
-func NewUserHandler(us *services.UserService) *UserHandler {
-	return &UserHandler{userService: us}
+func NewUserHandler(us *services.UserService, logger logx.Logger) *UserHandler {
+	return &UserHandler{userService: us, logger: logger.With(logx.String("module", "user_handler"))}
 }
 
 // GetUsers 获取所有用户
 func (h *UserHandler) GetUsers(c echo.Context) error {
-	users, err := h.userService.GetAll()
+	ctx := c.Request().Context()
+	users, err := h.userService.GetAll(ctx)
 	if err != nil {
+		h.logger.Error(ctx, "list users failed", logx.Err(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.JSON(http.StatusOK, users)
@@ -27,8 +31,9 @@ func (h *UserHandler) GetUsers(c echo.Context) error {
 
 // GetUser 获取单个用户
 func (h *UserHandler) GetUser(c echo.Context) error {
+	ctx := c.Request().Context()
 	id, _ := strconv.Atoi(c.Param("id"))
-	user, err := h.userService.GetByID(id)
+	user, err := h.userService.GetByID(ctx, id)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "User not found")
 	}
@@ -37,17 +42,21 @@ func (h *UserHandler) GetUser(c echo.Context) error {
 
 // CreateUser 创建用户
 func (h *UserHandler) CreateUser(c echo.Context) error {
+	ctx := c.Request().Context()
 	user := new(models.User)
 	if err := c.Bind(user); err != nil {
+		h.logger.Warn(ctx, "bind user failed", logx.Err(err))
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
 	if err := c.Validate(user); err != nil {
+		h.logger.Warn(ctx, "invalid user", logx.Err(err))
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	createdUser, err := h.userService.Create(user)
+	createdUser, err := h.userService.Create(ctx, user)
 	if err != nil {
+		h.logger.Error(ctx, "create user failed", logx.Err(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
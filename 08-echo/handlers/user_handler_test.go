@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"01-zap/logx"
 	"08-echo/services"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -10,23 +11,44 @@ import (
 	"testing"
 )
 
+// newTestContext 创建注册了校验器的echo实例和POST请求的上下文
+func newTestContext(body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = NewValidator()
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	return e.NewContext(req, rec), rec
+}
+
 func TestUserHandler_CreateUser(t *testing.T) {
 	// 设置
-	e := echo.New()
-	userService := services.NewUserService()
-	handler := NewUserHandler(userService)
+	userService := services.NewUserService(logx.Nop())
+	handler := NewUserHandler(userService, logx.Nop())
 
 	// 测试数据
-	userJSON := `{"username":"test","email":"test@example.com","age":25}`
-
-	// 创建请求
-	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(userJSON))
-	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	rec := httptest.NewRecorder()
-	c := e.NewContext(req, rec)
+	c, rec := newTestContext(`{"username":"test","email":"test@example.com","age":25}`)
 
 	// 执行
 	if assert.NoError(t, handler.CreateUser(c)) {
 		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `{"id":1,"username":"test","email":"test@example.com","age":25}`, rec.Body.String())
+	}
+}
+
+func TestUserHandler_CreateUserInvalid(t *testing.T) {
+	handler := NewUserHandler(services.NewUserService(logx.Nop()), logx.Nop())
+
+	for _, body := range []string{
+		`{"email":"test@example.com","age":25}`,
+		`{"username":"test","email":"not-an-email","age":25}`,
+		`{"username":"test","email":"test@example.com","age":200}`,
+	} {
+		c, _ := newTestContext(body)
+		err := handler.CreateUser(c)
+		var he *echo.HTTPError
+		if assert.ErrorAs(t, err, &he, body) {
+			assert.Equal(t, http.StatusBadRequest, he.Code, body)
+		}
 	}
 }
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// Validator 实现echo.Validator，按models中的validate标签校验请求体
+// 需要在echo实例上注册：e.Validator = handlers.NewValidator()，否则c.Validate返回 validator not registered
+type Validator struct {
+	validate *validator.Validate
+}
+
+// NewValidator 创建校验器
+func NewValidator() *Validator {
+	return &Validator{validate: validator.New(validator.WithRequiredStructEnabled())}
+}
+
+// Validate 校验结构体，失败时返回validator.ValidationErrors
+func (v *Validator) Validate(i interface{}) error {
+	return v.validate.Struct(i)
+}
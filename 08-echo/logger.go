@@ -0,0 +1,44 @@
+package main
+
+import (
+	zapconfig "01-zap/cmd/demo1/config"
+	"01-zap/logx"
+	logrusconfig "03-logrus/config"
+	"03-logrus/logrusx"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newLogger 根据环境变量LOG_BACKEND选择日志实现：zap（默认）、logrus 或 slog
+// 返回的函数在退出前调用，刷新并关闭日志输出
+func newLogger(backend string) (logx.Logger, func(), error) {
+	switch backend {
+	case "zap", "":
+		zapconfig.InitLogger()
+		return logx.NewZap(zapconfig.Logger), func() { zapconfig.Close() }, nil
+	case "logrus":
+		logrusconfig.InitLogger()
+		return logrusx.New(logrusconfig.Log), func() { logrusconfig.Close() }, nil
+	case "slog":
+		handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{AddSource: true, Level: slog.LevelDebug})
+		return logx.NewSlog(slog.New(handler)), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown log backend %q", backend)
+	}
+}
+
+// requestLogger 把请求ID放入ctx，handlers和services的日志都会带上requestID字段
+// 需要放在middleware.RequestID之后
+func requestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Response().Header().Get(echo.HeaderXRequestID)
+		if id != "" {
+			req := c.Request()
+			c.SetRequest(req.WithContext(logx.WithContext(req.Context(), logx.String("requestID", id))))
+		}
+		return next(c)
+	}
+}
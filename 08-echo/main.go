@@ -1,9 +1,24 @@
 package main
 
 import (
+	"01-zap/audit"
+	"01-zap/logx"
+	"01-zap/rotate"
+	"08-echo/handlers"
+	"08-echo/services"
+	"context"
+	"crypto/subtle"
+	"errors"
 	"html/template"
 	"io"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -26,6 +41,54 @@ type User struct {
 }
 
 func main() {
+	// handlers和services通过logx输出日志，启动时选择具体实现
+	logger, closeLogger, err := newLogger(os.Getenv("LOG_BACKEND"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeLogger()
+
+	// 打开审计日志，记录用户的创建、更新和删除，检查点的HMAC密钥通过环境变量AUDIT_HMAC_KEY指定
+	key := os.Getenv("AUDIT_HMAC_KEY")
+	if key == "" {
+		log.Println("AUDIT_HMAC_KEY is not set, audit checkpoints will not be signed")
+	}
+	auditLog, err = audit.Open(audit.Options{
+		Rotate: rotate.Options{
+			Pattern:      "logs/audit/audit.log.%Y%m%d",
+			LinkName:     "logs/audit/audit.log",
+			RotationTime: 24 * time.Hour,
+			Compress:     true,
+		},
+		Key: []byte(key),
+	})
+	if err != nil {
+		log.Fatalf("open audit log: %v", err)
+	}
+	defer auditLog.Close()
+
+	e := newServer(logger, adminAccounts())
+
+	// 启动服务器，收到SIGINT/SIGTERM后停止接收请求，返回后关闭审计日志，写入最后的检查点
+	go func() {
+		if err := e.Start(":8080"); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		e.Logger.Error(err)
+	}
+}
+
+// newServer 创建Echo实例并注册路由，accounts是修改用户的接口允许的用户名和密码
+func newServer(logger logx.Logger, accounts map[string]string) *echo.Echo {
 	// 创建 Echo 实例
 	e := echo.New()
 
@@ -68,17 +131,89 @@ func main() {
 	// 根据 ID 获取单个用户
 	api.GET("/users/:id", getUserByID)
 
+	// 修改用户的接口需要HTTP Basic认证，审计日志的操作者是认证的用户名
+	admin := api.Group("", basicAuth(accounts))
+
 	// 创建新用户
-	api.POST("/users", createUser)
+	admin.POST("/users", createUser)
 
 	// 更新用户
-	api.PUT("/users/:id", updateUser)
+	admin.PUT("/users/:id", updateUser)
 
 	// 删除用户
-	api.DELETE("/users/:id", deleteUser)
+	admin.DELETE("/users/:id", deleteUser)
+
+	// 基于handlers和services的用户接口，日志带有请求ID
+	// 请求体按models中的validate标签校验
+	e.Validator = handlers.NewValidator()
+	userHandler := handlers.NewUserHandler(services.NewUserService(logger), logger)
+	v2 := e.Group("/api/v2", middleware.RequestID(), requestLogger)
+	v2.GET("/users", userHandler.GetUsers)
+	v2.GET("/users/:id", userHandler.GetUser)
+	v2.POST("/users", userHandler.CreateUser)
+
+	return e
+}
 
-	// 启动服务器
-	e.Logger.Fatal(e.Start(":8080"))
+// adminAccounts 从环境变量ADMIN_ACCOUNTS读取账号，格式为 user1:pass1,user2:pass2
+// 未设置时使用演示账号 admin:admin
+func adminAccounts() map[string]string {
+	accounts := map[string]string{}
+	for _, pair := range strings.Split(os.Getenv("ADMIN_ACCOUNTS"), ",") {
+		user, pass, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && user != "" {
+			accounts[user] = pass
+		}
+	}
+	if len(accounts) == 0 {
+		log.Println("ADMIN_ACCOUNTS is not set, using the demo account admin:admin")
+		accounts["admin"] = "admin"
+	}
+	return accounts
+}
+
+// basicAuth 校验HTTP Basic认证，通过后把用户名保存到authUserKey
+func basicAuth(accounts map[string]string) echo.MiddlewareFunc {
+	return middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
+		want, ok := accounts[username]
+		// 用户不存在时也比较一次，避免通过耗时判断用户是否存在
+		if subtle.ConstantTimeCompare([]byte(password), []byte(want)) != 1 || !ok {
+			return false, nil
+		}
+		c.Set(authUserKey, username)
+		return true, nil
+	})
+}
+
+// 审计日志
+var auditLog *audit.Writer
+
+// authUserKey 认证中间件校验通过后通过c.Set保存的用户名，如middleware.BasicAuth的校验函数中设置
+const authUserKey = "auth_user"
+
+// auditUser 记录用户操作
+// 操作者只取认证中间件保存的用户名，客户端可以随意设置的X-User请求头只作为数据记录
+func auditUser(c echo.Context, action string, user User) {
+	actor := "unauthenticated"
+	if name, _ := c.Get(authUserKey).(string); name != "" {
+		actor = name
+	}
+	data := map[string]any{
+		"name": user.Name,
+		"ip":   c.RealIP(),
+	}
+	if claimed := c.Request().Header.Get("X-User"); claimed != "" {
+		data["claimed_user"] = claimed
+	}
+	err := auditLog.Log(audit.Event{
+		Actor:    actor,
+		Action:   action,
+		Resource: "user:" + strconv.Itoa(user.ID),
+		Data:     data,
+	})
+	if err != nil {
+		c.Logger().Errorf("write audit log: %v", err)
+	}
 }
 
 // 获取所有用户
@@ -113,6 +248,7 @@ func createUser(c echo.Context) error {
 	}
 	// 这里可以添加实际的数据库创建逻辑
 	newUser.ID = 3 // 示例 ID
+	auditUser(c, "user.create", newUser)
 	return c.JSON(http.StatusCreated, newUser)
 }
 
@@ -128,6 +264,7 @@ func updateUser(c echo.Context) error {
 	if id != "1" {
 		return c.JSON(http.StatusNotFound, map[string]string{"message": "User not found"})
 	}
+	auditUser(c, "user.update", updatedUser)
 	return c.JSON(http.StatusOK, updatedUser)
 }
 
@@ -138,5 +275,6 @@ func deleteUser(c echo.Context) error {
 	if id != "1" {
 		return c.JSON(http.StatusNotFound, map[string]string{"message": "User not found"})
 	}
+	auditUser(c, "user.delete", User{ID: 1})
 	return c.JSON(http.StatusOK, map[string]string{"message": "User deleted"})
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"01-zap/audit"
+	"01-zap/logx"
+	"01-zap/rotate"
+	"bufio"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// auditEvents 读取审计日志中的事件记录
+func auditEvents(t *testing.T, pattern string) []audit.Record {
+	t.Helper()
+	files, err := rotate.Files(pattern, "")
+	require.NoError(t, err)
+	var events []audit.Record
+	for _, name := range files {
+		f, err := os.Open(name)
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var rec audit.Record
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+			if rec.Type == "event" {
+				events = append(events, rec)
+			}
+		}
+		f.Close()
+	}
+	return events
+}
+
+func TestCreateUserAuditActor(t *testing.T) {
+	pattern := filepath.Join(t.TempDir(), "audit.log.%Y%m%d")
+	var err error
+	auditLog, err = audit.Open(audit.Options{Rotate: rotate.Options{Pattern: pattern}})
+	require.NoError(t, err)
+	e := newServer(logx.Nop(), map[string]string{"alice": "secret"})
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(`{"name":"Carol"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("X-User", "mallory")
+		return req
+	}
+
+	// 没有认证或密码错误的请求不能修改用户
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, newRequest())
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := newRequest()
+	req.SetBasicAuth("alice", "wrong")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = newRequest()
+	req.SetBasicAuth("alice", "secret")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	require.NoError(t, auditLog.Close())
+	events := auditEvents(t, pattern)
+	require.Len(t, events, 1)
+	// 操作者是认证的用户名，X-User请求头只作为数据记录
+	assert.Equal(t, "alice", events[0].Actor)
+	assert.Equal(t, "user.create", events[0].Action)
+	assert.Equal(t, "mallory", events[0].Data["claimed_user"])
+}
+
+func TestAdminAccounts(t *testing.T) {
+	t.Setenv("ADMIN_ACCOUNTS", "alice:secret, bob:hunter2,invalid")
+	assert.Equal(t, map[string]string{"alice": "secret", "bob": "hunter2"}, adminAccounts())
+
+	t.Setenv("ADMIN_ACCOUNTS", "")
+	assert.Equal(t, map[string]string{"admin": "admin"}, adminAccounts())
+}
@@ -1,35 +1,43 @@
 package services
 
 import (
+	"01-zap/logx"
 	"08-echo/models"
+	"context"
 	"errors"
 )
 
 type UserService struct {
-	users []models.User
+	users  []models.User
+	logger logx.Logger
 }
 
-func NewUserService() *UserService {
+// NewUserService 日志通过logx.Logger输出，具体使用zap、logrus还是slog由main决定
+func NewUserService(logger logx.Logger) *UserService {
 	return &UserService{
-		users: make([]models.User, 0),
+		users:  make([]models.User, 0),
+		logger: logger.With(logx.String("module", "user_service")),
 	}
 }
 
-func (s *UserService) GetAll() ([]models.User, error) {
+func (s *UserService) GetAll(ctx context.Context) ([]models.User, error) {
+	s.logger.Debug(ctx, "list users", logx.Int("count", len(s.users)))
 	return s.users, nil
 }
 
-func (s *UserService) GetByID(id int) (*models.User, error) {
+func (s *UserService) GetByID(ctx context.Context, id int) (*models.User, error) {
 	for _, user := range s.users {
 		if user.ID == id {
 			return &user, nil
 		}
 	}
+	s.logger.Debug(ctx, "user not found", logx.Int("id", id))
 	return nil, errors.New("user not found")
 }
 
-func (s *UserService) Create(user *models.User) (*models.User, error) {
+func (s *UserService) Create(ctx context.Context, user *models.User) (*models.User, error) {
 	user.ID = len(s.users) + 1
 	s.users = append(s.users, *user)
+	s.logger.Info(ctx, "user created", logx.Int("id", user.ID), logx.String("username", user.Username))
 	return user, nil
 }